@@ -0,0 +1,143 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/i96751414/torrest/bittorrent"
+)
+
+const (
+	zipArchiveFormat = "zip"
+	tarArchiveFormat = "tar"
+)
+
+type archiveWriter interface {
+	CreateFile(name string, size int64, modTime time.Time) (io.Writer, error)
+	Close() error
+}
+
+type zipArchiveWriter struct {
+	*zip.Writer
+}
+
+func (z *zipArchiveWriter) CreateFile(name string, size int64, modTime time.Time) (io.Writer, error) {
+	header := &zip.FileHeader{
+		Name:               name,
+		Method:             zip.Store,
+		UncompressedSize64: uint64(size),
+		Modified:           modTime,
+	}
+	header.SetMode(0644)
+	return z.CreateHeader(header)
+}
+
+type tarArchiveWriter struct {
+	*tar.Writer
+}
+
+func (t *tarArchiveWriter) CreateFile(name string, size int64, modTime time.Time) (io.Writer, error) {
+	return t.Writer, t.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  modTime,
+	})
+}
+
+// @Summary Download Archive
+// @Description download all torrent files, or the ones inside a directory, as a single uncompressed archive
+// @ID archive-torrent
+// @Produce application/zip,application/x-tar
+// @Param infoHash path string true "torrent info hash"
+// @Param format query string false "archive format (zip or tar)"
+// @Param path query string false "directory to archive, as in the files paths"
+// @Success 200
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/archive [get]
+func archiveTorrent(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			format := ctx.DefaultQuery("format", zipArchiveFormat)
+			if format != zipArchiveFormat && format != tarArchiveFormat {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse("'format' must be either zip or tar"))
+				return
+			}
+
+			files, err := torrent.Files()
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
+				return
+			}
+
+			dir := strings.Trim(ctx.Query("path"), "/")
+			var selected []*bittorrent.File
+			for _, file := range files {
+				if filePath := filepath.ToSlash(file.Path()); dir == "" || strings.HasPrefix(filePath, dir+"/") {
+					selected = append(selected, file)
+				}
+			}
+			if len(selected) == 0 {
+				ctx.JSON(http.StatusNotFound, NewErrorResponse("no files inside the provided path"))
+				return
+			}
+
+			// Entries are relative to the parent of the archived directory
+			name := torrent.GetInfo().Name
+			prefix := ""
+			if dir != "" {
+				name = path.Base(dir)
+				if parent := path.Dir(dir); parent != "." {
+					prefix = parent + "/"
+				}
+			}
+
+			var archive archiveWriter
+			if format == zipArchiveFormat {
+				ctx.Header("Content-Type", "application/zip")
+				archive = &zipArchiveWriter{zip.NewWriter(ctx.Writer)}
+			} else {
+				ctx.Header("Content-Type", "application/x-tar")
+				archive = &tarArchiveWriter{tar.NewWriter(ctx.Writer)}
+			}
+			ctx.Header("Content-Disposition", contentDisposition("attachment", name+"."+format))
+			ctx.Status(http.StatusOK)
+
+			if err := writeArchive(ctx, archive, selected, prefix); err != nil {
+				log.Errorf("Failed writing archive for '%s': %s", torrent.InfoHash(), err)
+				ctx.Abort()
+			}
+		})
+	}
+}
+
+func writeArchive(ctx *gin.Context, archive archiveWriter, files []*bittorrent.File, prefix string) error {
+	modTime := time.Now()
+	for _, file := range files {
+		name := strings.TrimPrefix(filepath.ToSlash(file.Path()), prefix)
+		w, err := archive.CreateFile(name, file.Length(), modTime)
+		if err != nil {
+			return err
+		}
+
+		reader := file.NewReaderContext(ctx.Request.Context())
+		_, err = io.CopyN(w, reader, file.Length())
+		if e := reader.Close(); e != nil {
+			log.Errorf("Error closing file reader: %s\n", e)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/i96751414/torrest/bittorrent"
+)
+
+// @Summary Blocklist Status
+// @Description get the number of rules and the result of the last blocklist load
+// @ID blocklist-status
+// @Produce json
+// @Success 200 {object} bittorrent.BlocklistStatus
+// @Router /blocklist/status [get]
+func blocklistStatus(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, service.BlocklistStatus())
+	}
+}
+
+// @Summary Reload Blocklist
+// @Description reload the blocklist sources in background
+// @ID blocklist-reload
+// @Produce json
+// @Success 200 {object} models.MessageResponse
+// @Router /blocklist/reload [get]
+func reloadBlocklist(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		service.ReloadBlocklist()
+		ctx.JSON(http.StatusOK, NewMessageResponse("blocklist reload scheduled"))
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gin-gonic/gin"
+	"github.com/i96751414/torrest/bittorrent"
+)
+
+var directoryIndex = template.Must(template.New("index").Funcs(template.FuncMap{
+	"bytes":      func(size int64) string { return humanize.Bytes(uint64(size)) },
+	"pathEscape": url.PathEscape,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of /{{.Path}}</title></head>
+<body>
+<h1>Index of /{{.Path}}</h1>
+<pre>
+{{if .Path}}<a href="../">../</a>
+{{end}}{{range .Entries}}{{if .IsDir}}<a href="./{{pathEscape .Name}}/">{{.Name}}/</a>{{else}}<a href="./{{pathEscape .Name}}">{{.Name}}</a>{{end}}	{{bytes .Length}}
+{{end}}</pre>
+</body>
+</html>
+`))
+
+type DirectoryEntry struct {
+	Name   string `json:"name" example:"video.mkv"`
+	Path   string `json:"path" example:"folder/video.mkv"`
+	IsDir  bool   `json:"is_dir" example:"false"`
+	Length int64  `json:"length" example:"1073741824"`
+	FileId *int   `json:"file_id,omitempty" example:"0"`
+}
+
+type directoryIndexData struct {
+	Path    string
+	Entries []DirectoryEntry
+}
+
+type treeEntry struct {
+	name   string
+	length int64
+	file   *bittorrent.File
+}
+
+// listDirectory returns the direct children of dir, where each file path is
+// given by filePath. Directories come first, sorted by name.
+func listDirectory(files []*bittorrent.File, dir string, filePath func(*bittorrent.File) string) (entries []*treeEntry, found bool) {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+
+	dirs := make(map[string]*treeEntry)
+	for _, file := range files {
+		p := filePath(file)
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		found = true
+		if i := strings.IndexByte(p[len(prefix):], '/'); i >= 0 {
+			name := p[len(prefix) : len(prefix)+i]
+			entry, ok := dirs[name]
+			if !ok {
+				entry = &treeEntry{name: name}
+				dirs[name] = entry
+				entries = append(entries, entry)
+			}
+			entry.length += file.Length()
+		} else {
+			entries = append(entries, &treeEntry{name: p[len(prefix):], length: file.Length(), file: file})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if (entries[i].file == nil) != (entries[j].file == nil) {
+			return entries[i].file == nil
+		}
+		return entries[i].name < entries[j].name
+	})
+	return entries, found || dir == ""
+}
+
+func slashFilePath(file *bittorrent.File) string {
+	return filepath.ToSlash(file.Path())
+}
+
+// @Summary Browse Torrent
+// @Description serve a file given its path or list the contents of a directory, either in html or json
+// @ID browse-torrent
+// @Produce html,json
+// @Param infoHash path string true "torrent info hash"
+// @Param path path string true "file or directory path"
+// @Param disposition query string false "content disposition of served files (inline or attachment)"
+// @Success 200 {array} DirectoryEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/browse/{path} [get]
+func browseTorrent(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			files, err := torrent.Files()
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
+				return
+			}
+
+			dir := strings.Trim(ctx.Param("path"), "/")
+			for _, file := range files {
+				if slashFilePath(file) == dir {
+					serveTorrentFile(ctx, file)
+					return
+				}
+			}
+
+			entries, found := listDirectory(files, dir, slashFilePath)
+			if !found {
+				ctx.JSON(http.StatusNotFound, NewErrorResponse("no such file or directory"))
+				return
+			}
+
+			// Make sure relative links are resolved inside the directory
+			if !strings.HasSuffix(ctx.Request.URL.Path, "/") {
+				location := "./" + url.PathEscape(path.Base(ctx.Request.URL.Path)) + "/"
+				if ctx.Request.URL.RawQuery != "" {
+					location += "?" + ctx.Request.URL.RawQuery
+				}
+				ctx.Redirect(http.StatusMovedPermanently, location)
+				return
+			}
+
+			response := make([]DirectoryEntry, len(entries))
+			for i, entry := range entries {
+				response[i] = DirectoryEntry{
+					Name:   entry.name,
+					Path:   path.Join(dir, entry.name),
+					IsDir:  entry.file == nil,
+					Length: entry.length,
+				}
+				if entry.file != nil {
+					fileId := entry.file.Id()
+					response[i].FileId = &fileId
+				}
+			}
+
+			if ctx.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+				ctx.JSON(http.StatusOK, response)
+			} else {
+				ctx.Header("Content-Type", "text/html; charset=utf-8")
+				ctx.Status(http.StatusOK)
+				if err := directoryIndex.Execute(ctx.Writer, directoryIndexData{Path: dir, Entries: response}); err != nil {
+					log.Errorf("Failed writing directory index: %s", err)
+				}
+			}
+		})
+	}
+}
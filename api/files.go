@@ -1,16 +1,27 @@
 package api
 
 import (
-	"github.com/i96751414/torrest/util"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/i96751414/torrest/bittorrent"
 	"github.com/i96751414/torrest/settings"
+	"github.com/i96751414/torrest/util"
+)
+
+const (
+	defaultBufferMode   = "default"
+	containerBufferMode = "container"
 )
 
+// Same as the number of bytes considered by http.DetectContentType
+const sniffLength = 512
+
 type FileHash struct {
 	Hash string `json:"hash"`
 }
@@ -22,35 +33,92 @@ type FileHash struct {
 // @Param infoHash path string true "torrent info hash"
 // @Param file path integer true "file id"
 // @Param buffer query boolean false "buffer file"
-// @Success 200 {object} MessageResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
+// @Param buffer_mode query string false "buffering mode (default or container)"
+// @Param duration query number false "media duration hint in seconds, used if not available from the container"
+// @Param priority query string false "download priority, either low, default, high, top or a value between 1 and 7" default(default)
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/files/{file}/download [get]
 func downloadFile(config *settings.Settings, service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		onGetFile(ctx, service, func(file *bittorrent.File) {
-			file.SetPriority(bittorrent.DefaultPriority)
+			bufferMode := ctx.DefaultQuery("buffer_mode", defaultBufferMode)
+			if bufferMode != defaultBufferMode && bufferMode != containerBufferMode {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse("'buffer_mode' must be either default or container"))
+				return
+			}
+			if !setDurationHint(ctx, file) {
+				return
+			}
+			priority, ok := downloadPriorityQuery(ctx)
+			if !ok {
+				return
+			}
+
+			file.SetPriority(priority)
 			if ctx.DefaultQuery("buffer", "false") == "true" {
-				bufferSize := int64(float64(file.Length()) * startBufferPercent)
-				if bufferSize < config.BufferSize {
-					bufferSize = config.BufferSize
+				startBufferSize, endBufferSize := file.BufferSizes(config.BufferSize)
+				if bufferMode == containerBufferMode {
+					file.BufferContainer(startBufferSize, endBufferSize)
+				} else {
+					file.Buffer(startBufferSize, endBufferSize)
 				}
-				file.Buffer(bufferSize, endBufferSize)
 			}
 			ctx.JSON(http.StatusOK, NewMessageResponse("file '%d' is downloading", file.Id()))
 		})
 	}
 }
 
+// @Summary Buffer File Range
+// @Description buffer a range of the file from torrent given its id, e.g. before seeking
+// @ID buffer-file
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param file path integer true "file id"
+// @Param offset query integer true "range start offset"
+// @Param length query integer false "range length (defaults to the configured buffer size)"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/files/{file}/buffer [get]
+func bufferFile(config *settings.Settings, service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetFile(ctx, service, func(file *bittorrent.File) {
+			offset, err := strconv.ParseInt(ctx.Query("offset"), 10, 64)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse("'offset' must be integer"))
+				return
+			}
+			length := config.BufferSize
+			if lengthString := ctx.Query("length"); lengthString != "" {
+				if length, err = strconv.ParseInt(lengthString, 10, 64); err != nil {
+					ctx.JSON(http.StatusBadRequest, NewErrorResponse("'length' must be integer"))
+					return
+				}
+			}
+			if offset+length > file.Length() {
+				length = file.Length() - offset
+			}
+
+			if err := file.BufferRange(offset, length); err != nil {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusOK, NewMessageResponse("buffering file '%d' range [%d, %d)", file.Id(), offset, offset+length))
+		})
+	}
+}
+
 // @Summary Stop File Download
 // @Description stop file download from torrent given its id
 // @ID stop-file
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
 // @Param file path integer true "file id"
-// @Success 200 {object} MessageResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/files/{file}/stop [get]
 func stopFile(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -67,9 +135,9 @@ func stopFile(service *bittorrent.Service) gin.HandlerFunc {
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
 // @Param file path integer true "file id"
-// @Success 200 {object} bittorrent.FileInfo
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
+// @Success 200 {object} models.FileInfo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/files/{file}/info [get]
 func fileInfo(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -85,18 +153,35 @@ func fileInfo(service *bittorrent.Service) gin.HandlerFunc {
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
 // @Param file path integer true "file id"
-// @Success 200 {object} bittorrent.FileStatus
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
+// @Param duration query number false "media duration hint in seconds, used if not available from the container"
+// @Success 200 {object} models.FileStatus
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/files/{file}/status [get]
 func fileStatus(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		onGetFile(ctx, service, func(file *bittorrent.File) {
-			ctx.JSON(http.StatusOK, file.Status())
+			if setDurationHint(ctx, file) {
+				ctx.JSON(http.StatusOK, file.Status())
+			}
 		})
 	}
 }
 
+// setDurationHint sets the file duration hint from the 'duration' query
+// parameter, if present. Can produce 400 (StatusBadRequest) http error.
+func setDurationHint(ctx *gin.Context, file *bittorrent.File) bool {
+	if durationString := ctx.Query("duration"); durationString != "" {
+		duration, err := strconv.ParseFloat(durationString, 64)
+		if err != nil || duration < 0 {
+			ctx.JSON(http.StatusBadRequest, NewErrorResponse("'duration' must be a positive number"))
+			return false
+		}
+		file.SetDurationHint(time.Duration(duration * float64(time.Second)))
+	}
+	return true
+}
+
 // @Summary Calculate file hash
 // @Description calculate file hash suitable for opensubtitles
 // @ID file-hash
@@ -104,9 +189,9 @@ func fileStatus(service *bittorrent.Service) gin.HandlerFunc {
 // @Param infoHash path string true "torrent info hash"
 // @Param file path integer true "file id"
 // @Success 200 {object} FileHash
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/files/{file}/hash [get]
 func fileHash(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -130,23 +215,102 @@ func fileHash(service *bittorrent.Service) gin.HandlerFunc {
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
 // @Param file path integer true "file id"
+// @Param disposition query string false "content disposition (inline or attachment)"
 // @Success 200
-// @Failure 400 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/files/{file}/serve [get]
 func serveFile(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		onGetFile(ctx, service, func(file *bittorrent.File) {
-			reader := file.NewReader()
-			reader.RegisterCloseNotifier(ctx.Writer.CloseNotify())
-			http.ServeContent(ctx.Writer, ctx.Request, file.Name(), time.Time{}, reader)
-			if err := reader.Close(); err != nil {
-				log.Errorf("Error closing file reader: %s\n", err)
-			}
+			serveTorrentFile(ctx, file)
 		})
 	}
 }
 
+// serveTorrentFile serves the file contents, handling range and conditional
+// requests. HEAD requests never read from the torrent, unless the data needed
+// to detect the content type is already available.
+func serveTorrentFile(ctx *gin.Context, file *bittorrent.File) {
+	if disposition := ctx.Query("disposition"); disposition != "" {
+		if disposition != "inline" && disposition != "attachment" {
+			ctx.JSON(http.StatusBadRequest, NewErrorResponse("'disposition' must be either inline or attachment"))
+			return
+		}
+		ctx.Header("Content-Disposition", contentDisposition(disposition, file.Name()))
+	}
+
+	// Torrent files contents never change, so the etag is strong
+	ctx.Header("ETag", fmt.Sprintf(`"%s-%d"`, file.Torrent().InfoHash(), file.Id()))
+	ctx.Header("Content-Type", fileContentType(ctx, file))
+
+	if ctx.Request.Method == http.MethodHead {
+		content := io.NewSectionReader(unavailableReaderAt{}, 0, file.Length())
+		http.ServeContent(ctx.Writer, ctx.Request, file.Name(), file.Torrent().AddedTime(), content)
+		return
+	}
+
+	reader := file.NewReader()
+	reader.RegisterCloseNotifier(ctx.Writer.CloseNotify())
+	http.ServeContent(ctx.Writer, ctx.Request, file.Name(), file.Torrent().AddedTime(), reader)
+	if err := reader.Close(); err != nil {
+		log.Errorf("Error closing file reader: %s\n", err)
+	}
+}
+
+// fileContentType returns the content type of the file based on its
+// extension or, when unknown, on its first bytes
+func fileContentType(ctx *gin.Context, file *bittorrent.File) string {
+	if contentType := util.MimeTypeByName(file.Name()); contentType != "" {
+		return contentType
+	}
+
+	sniffLen := int64(sniffLength)
+	if file.Length() < sniffLen {
+		sniffLen = file.Length()
+	}
+	if ctx.Request.Method != http.MethodHead || file.HasBytes(0, sniffLen) {
+		buf := make([]byte, sniffLen)
+		if n, err := file.ReadAtContext(ctx.Request.Context(), buf, 0); err == nil || err == io.EOF {
+			return http.DetectContentType(buf[:n])
+		}
+	}
+	return "application/octet-stream"
+}
+
+// contentDisposition formats the Content-Disposition header value, with an
+// ASCII fallback filename and the RFC 5987 encoded UTF-8 filename if needed
+func contentDisposition(dispositionType, filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, filename)
+
+	value := dispositionType + `; filename="` + fallback + `"`
+	if fallback != filename {
+		var encoded strings.Builder
+		for _, c := range []byte(filename) {
+			if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+				encoded.WriteByte(c)
+			} else {
+				fmt.Fprintf(&encoded, "%%%02X", c)
+			}
+		}
+		value += "; filename*=UTF-8''" + encoded.String()
+	}
+	return value
+}
+
+// unavailableReaderAt is used as the content of HEAD requests, which must
+// never read the file data
+type unavailableReaderAt struct{}
+
+func (unavailableReaderAt) ReadAt(_ []byte, _ int64) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
 // Can produce 400 (StatusBadRequest) and 404 (StatusNotFound) http errors
 func onGetFile(ctx *gin.Context, service *bittorrent.Service, f func(*bittorrent.File)) {
 	fileString := ctx.Param("file")
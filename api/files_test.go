@@ -0,0 +1,51 @@
+package api
+
+import "testing"
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name            string
+		dispositionType string
+		filename        string
+		expected        string
+	}{
+		{
+			name:            "ascii",
+			dispositionType: "attachment",
+			filename:        "video.mkv",
+			expected:        `attachment; filename="video.mkv"`,
+		},
+		{
+			name:            "inline",
+			dispositionType: "inline",
+			filename:        "My Video (2020).mp4",
+			expected:        `inline; filename="My Video (2020).mp4"`,
+		},
+		{
+			name:            "quotes and backslashes",
+			dispositionType: "attachment",
+			filename:        `a "b"\c.txt`,
+			expected:        `attachment; filename="a _b__c.txt"; filename*=UTF-8''a%20%22b%22%5Cc.txt`,
+		},
+		{
+			name:            "non ascii",
+			dispositionType: "attachment",
+			filename:        "café.mp4",
+			expected:        `attachment; filename="caf_.mp4"; filename*=UTF-8''caf%C3%A9.mp4`,
+		},
+		{
+			name:            "control characters",
+			dispositionType: "attachment",
+			filename:        "a\nb.txt",
+			expected:        `attachment; filename="a_b.txt"; filename*=UTF-8''a%0Ab.txt`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if value := contentDisposition(test.dispositionType, test.filename); value != test.expected {
+				t.Fatalf("expected %s, got %s", test.expected, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/i96751414/torrest/bittorrent"
+	"github.com/i96751414/torrest/models"
+)
+
+const defaultResolveTimeout = 60
+
+type ResolveResponse struct {
+	*models.TorrentInfo
+	Files []*models.FileInfo `json:"files"`
+	Kept  bool               `json:"kept" example:"false"`
+}
+
+// @Summary Resolve Magnet
+// @Description add a magnet without downloading, or reuse the existing torrent, and wait for its metadata, returning the list of files
+// @ID resolve
+// @Produce json
+// @Param uri query string true "magnet URI"
+// @Param timeout query integer false "maximum time to wait for the metadata, in seconds (up to 300)" default(60)
+// @Param keep query boolean false "keep the torrent after resolving it. Otherwise it is removed, unless added again by another request while resolving"
+// @Success 200 {object} ResolveResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 504 {object} models.ErrorResponse
+// @Router /resolve [get]
+func resolve(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		magnet := ctx.Query("uri")
+		if !strings.HasPrefix(magnet, "magnet:") {
+			ctx.JSON(http.StatusBadRequest, NewErrorResponse("Invalid magnet provided"))
+			return
+		}
+		timeout, ok := timeoutQuery(ctx, defaultResolveTimeout, maxWaitTimeout)
+		if !ok {
+			return
+		}
+		keep := ctx.DefaultQuery("keep", "false") == "true"
+
+		// Existing torrents are never removed, and neither are the ones claimed
+		// by other requests while resolving
+		var infoHash string
+		var err error
+		if keep {
+			infoHash, err = service.AddMagnet(magnet, false)
+		} else {
+			infoHash, err = service.AddResolvingMagnet(magnet)
+		}
+		if err == bittorrent.DuplicateTorrentError {
+			keep = true
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
+			return
+		}
+		if !keep {
+			defer func() {
+				if _, err := service.RemoveResolvedTorrent(infoHash); err != nil {
+					log.Errorf("Failed removing resolved torrent %s: %s", infoHash, err)
+				}
+			}()
+		}
+
+		torrent, err := service.GetTorrent(infoHash)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
+			return
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+		if err := torrent.WaitForMetadata(waitCtx); err == bittorrent.TimeoutError {
+			ctx.JSON(http.StatusGatewayTimeout, NewErrorResponse(err))
+			return
+		} else if err != nil {
+			ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
+			return
+		}
+
+		files, err := torrent.Files()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
+			return
+		}
+		response := ResolveResponse{TorrentInfo: torrent.GetInfo(), Files: make([]*models.FileInfo, len(files)), Kept: keep}
+		for i, file := range files {
+			response.Files[i] = file.Info()
+		}
+		if !keep {
+			// Removed before responding, as it may have been claimed meanwhile.
+			// The deferred removal is then a no-op.
+			removed, err := service.RemoveResolvedTorrent(infoHash)
+			if err != nil {
+				log.Errorf("Failed removing resolved torrent %s: %s", infoHash, err)
+			}
+			response.Kept = !removed
+		}
+		ctx.JSON(http.StatusOK, response)
+	}
+}
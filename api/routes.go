@@ -5,6 +5,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/i96751414/torrest/bittorrent"
 	_ "github.com/i96751414/torrest/docs"
+	"github.com/i96751414/torrest/models"
 	"github.com/i96751414/torrest/settings"
 	"github.com/op/go-logging"
 	swaggerFiles "github.com/swaggo/files"
@@ -14,16 +15,8 @@ import (
 
 var log = logging.MustGetLogger("api")
 
-type ErrorResponse struct {
-	Error string `json:"error" example:"Houston, we have a problem!"`
-}
-
-type MessageResponse struct {
-	Message string `json:"message" example:"done"`
-}
-
-func NewErrorResponse(err interface{}) *ErrorResponse {
-	r := ErrorResponse{}
+func NewErrorResponse(err interface{}) *models.ErrorResponse {
+	r := models.ErrorResponse{}
 	switch err.(type) {
 	case string:
 		r.Error = err.(string)
@@ -35,8 +28,8 @@ func NewErrorResponse(err interface{}) *ErrorResponse {
 	return &r
 }
 
-func NewMessageResponse(format string, a ...interface{}) *MessageResponse {
-	return &MessageResponse{Message: fmt.Sprintf(format, a...)}
+func NewMessageResponse(format string, a ...interface{}) *models.MessageResponse {
+	return &models.MessageResponse{Message: fmt.Sprintf(format, a...)}
 }
 
 // @title Torrest API
@@ -64,6 +57,11 @@ func Routes(config *settings.Settings, service *bittorrent.Service, origin strin
 	r.GET("/status", status(service))
 	r.GET("/pause", pause(service))
 	r.GET("/resume", resume(service))
+	r.GET("/bans", bans(service))
+	r.GET("/ban", banPeer(service))
+	r.GET("/unban", unbanPeer(service))
+	r.GET("/fsck", fsck(service))
+	r.GET("/resolve", resolve(service))
 
 	addRoute := r.Group("/add")
 	addRoute.GET("/magnet", addMagnet(service))
@@ -72,6 +70,12 @@ func Routes(config *settings.Settings, service *bittorrent.Service, origin strin
 	settingsRoutes := r.Group("/settings")
 	settingsRoutes.GET("/get", getSettings(config))
 	settingsRoutes.POST("/set", setSettings(config, service))
+	settingsRoutes.GET("/sources", getSettingsSources(config))
+	settingsRoutes.GET("/libtorrent", getLibtorrentSettings(service))
+
+	blocklistRoutes := r.Group("/blocklist")
+	blocklistRoutes.GET("/status", blocklistStatus(service))
+	blocklistRoutes.GET("/reload", reloadBlocklist(service))
 
 	torrentsRoutes := r.Group("/torrents")
 	torrentsRoutes.GET("/", listTorrents(service))
@@ -80,16 +84,36 @@ func Routes(config *settings.Settings, service *bittorrent.Service, origin strin
 	torrentsRoutes.GET("/:infoHash/remove", removeTorrent(service))
 	torrentsRoutes.GET("/:infoHash/info", torrentInfo(service))
 	torrentsRoutes.GET("/:infoHash/status", torrentStatus(service))
+	torrentsRoutes.GET("/:infoHash/metadata", torrentMetadata(service))
+	torrentsRoutes.GET("/:infoHash/torrent", torrentFile(service))
+	torrentsRoutes.GET("/:infoHash/magnet", torrentMagnet(service))
+	torrentsRoutes.GET("/:infoHash/add_url_seed", addUrlSeed(service))
+	torrentsRoutes.GET("/:infoHash/remove_url_seed", removeUrlSeed(service))
+	torrentsRoutes.GET("/:infoHash/wait/metadata", waitMetadata(service))
+	torrentsRoutes.GET("/:infoHash/wait/state", waitState(service))
 	torrentsRoutes.GET("/:infoHash/files", torrentFiles(service))
 	torrentsRoutes.GET("/:infoHash/download", downloadTorrent(service))
 	torrentsRoutes.GET("/:infoHash/stop", stopTorrent(service))
+	torrentsRoutes.GET("/:infoHash/select", selectFiles(service))
+	torrentsRoutes.GET("/:infoHash/archive", archiveTorrent(service))
+	torrentsRoutes.GET("/:infoHash/peers", torrentPeers(service))
+	torrentsRoutes.GET("/:infoHash/connect", connectPeer(service))
+	torrentsRoutes.GET("/:infoHash/bans", torrentBans(service))
+	torrentsRoutes.GET("/:infoHash/ban", banTorrentPeer(service))
+	torrentsRoutes.GET("/:infoHash/unban", unbanTorrentPeer(service))
+	torrentsRoutes.Any("/:infoHash/browse/*path", browseTorrent(service))
 	torrentsRoutes.GET("/:infoHash/files/:file/download", downloadFile(config, service))
+	torrentsRoutes.GET("/:infoHash/files/:file/buffer", bufferFile(config, service))
 	torrentsRoutes.GET("/:infoHash/files/:file/stop", stopFile(service))
 	torrentsRoutes.GET("/:infoHash/files/:file/info", fileInfo(service))
 	torrentsRoutes.GET("/:infoHash/files/:file/status", fileStatus(service))
 	torrentsRoutes.GET("/:infoHash/files/:file/hash", fileHash(service))
+	torrentsRoutes.GET("/:infoHash/files/:file/wait/buffer", waitBuffer(service))
+	torrentsRoutes.GET("/:infoHash/files/:file/wait/complete", waitComplete(service))
 	torrentsRoutes.Any("/:infoHash/files/:file/serve", serveFile(service))
 
+	webDAVRoutes(r, service)
+
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler,
 		ginSwagger.URL("/swagger/doc.json")))
 
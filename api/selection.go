@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/gin-gonic/gin"
+	"github.com/i96751414/torrest/bittorrent"
+)
+
+type SelectFilesResponse struct {
+	Files []int `json:"files" example:"0,2"`
+}
+
+// Can produce 400 (StatusBadRequest) http error
+func priorityQuery(ctx *gin.Context) (uint, bool) {
+	priority, err := bittorrent.ParsePriority(ctx.DefaultQuery("priority", "default"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+		return 0, false
+	}
+	return priority, true
+}
+
+// Can produce 400 (StatusBadRequest) http error
+func downloadPriorityQuery(ctx *gin.Context) (uint, bool) {
+	priority, ok := priorityQuery(ctx)
+	if ok && priority == bittorrent.DontDownloadPriority {
+		ctx.JSON(http.StatusBadRequest, NewErrorResponse("'priority' must not be dont_download, use stop instead"))
+		return 0, false
+	}
+	return priority, ok
+}
+
+// Can produce 400 (StatusBadRequest) http error
+func sizeQuery(ctx *gin.Context, key string) (int64, bool) {
+	value := ctx.Query(key)
+	if value == "" {
+		return 0, true
+	}
+	size, err := humanize.ParseBytes(value)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, NewErrorResponse("'"+key+"' must be a size, e.g. 100MB or 104857600"))
+		return 0, false
+	}
+	return int64(size), true
+}
+
+// @Summary Select Files
+// @Description set the priority of the torrent files matching all the provided criteria, e.g. glob=*.mkv&min_size=100MB&priority=high
+// @ID select-files
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param glob query string false "glob pattern matched against the file name, or the file path if it contains a separator"
+// @Param regex query string false "regular expression matched against the file path"
+// @Param ext query string false "comma separated list of file extensions"
+// @Param min_size query string false "minimum file size, e.g. 100MB"
+// @Param max_size query string false "maximum file size, e.g. 2GB"
+// @Param priority query string false "priority, either dont_download, low, default, high, top or a value between 0 and 7" default(default)
+// @Param exclusive query boolean false "do not download the files not matching the criteria"
+// @Success 200 {object} SelectFilesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/select [get]
+func selectFiles(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			priority, ok := priorityQuery(ctx)
+			if !ok {
+				return
+			}
+			minSize, ok := sizeQuery(ctx, "min_size")
+			if !ok {
+				return
+			}
+			maxSize, ok := sizeQuery(ctx, "max_size")
+			if !ok {
+				return
+			}
+			var extensions []string
+			if ext := ctx.Query("ext"); ext != "" {
+				extensions = strings.Split(ext, ",")
+			}
+
+			selector, err := bittorrent.NewFileSelector(ctx.Query("glob"), ctx.Query("regex"), extensions, minSize, maxSize)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+				return
+			}
+			exclusive := ctx.DefaultQuery("exclusive", "false") == "true"
+			if files, err := torrent.SelectFiles(selector, priority, exclusive); err == nil {
+				ctx.JSON(http.StatusOK, SelectFilesResponse{Files: files})
+			} else {
+				ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
+			}
+		})
+	}
+}
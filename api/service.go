@@ -7,17 +7,41 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/i96751414/torrest/bittorrent"
+	"github.com/i96751414/torrest/models"
 )
 
-type NewTorrentResponse struct {
-	InfoHash string `json:"info_hash" example:"000102030405060708090a0b0c0d0e0f10111213"`
+type autoSelectOptions struct {
+	mode   bittorrent.AutoSelectMode
+	buffer bool
+}
+
+// Can produce 400 (StatusBadRequest) http error
+func autoSelectQuery(ctx *gin.Context) (*autoSelectOptions, bool) {
+	mode, err := bittorrent.ParseAutoSelectMode(ctx.Query("auto_select"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+		return nil, false
+	}
+	return &autoSelectOptions{mode: mode, buffer: ctx.DefaultQuery("buffer", "false") == "true"}, true
+}
+
+func (o *autoSelectOptions) apply(service *bittorrent.Service, infoHash string) error {
+	if o.mode == bittorrent.AutoSelectNone {
+		return nil
+	}
+	torrent, err := service.GetTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	torrent.AutoSelect(o.mode, o.buffer)
+	return nil
 }
 
 // @Summary Status
 // @Description get service status
 // @ID status
 // @Produce json
-// @Success 200 {object} bittorrent.ServiceStatus
+// @Success 200 {object} models.ServiceStatus
 // @Router /status [get]
 func status(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -29,7 +53,7 @@ func status(service *bittorrent.Service) gin.HandlerFunc {
 // @Description pause service
 // @ID pause
 // @Produce json
-// @Success 200 {object} MessageResponse
+// @Success 200 {object} models.MessageResponse
 // @Router /pause [get]
 func pause(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -42,7 +66,7 @@ func pause(service *bittorrent.Service) gin.HandlerFunc {
 // @Description resume service
 // @ID resume
 // @Produce json
-// @Success 200 {object} MessageResponse
+// @Success 200 {object} models.MessageResponse
 // @Router /resume [get]
 func resume(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -51,6 +75,70 @@ func resume(service *bittorrent.Service) gin.HandlerFunc {
 	}
 }
 
+// @Summary Get Bans
+// @Description get all banned peers, both global and per torrent
+// @ID bans
+// @Produce json
+// @Success 200 {object} bittorrent.Bans
+// @Router /bans [get]
+func bans(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, service.Bans())
+	}
+}
+
+// @Summary Ban Peer
+// @Description ban peer ip address from all torrents
+// @ID ban-peer
+// @Produce json
+// @Param ip query string true "peer ip address"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /ban [get]
+func banPeer(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ip := ctx.Query("ip")
+		if err := service.BanPeer(ip); err == nil {
+			ctx.JSON(http.StatusOK, NewMessageResponse("peer %s banned", ip))
+		} else {
+			ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+		}
+	}
+}
+
+// @Summary Unban Peer
+// @Description remove global ban of peer ip address
+// @ID unban-peer
+// @Produce json
+// @Param ip query string true "peer ip address"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /unban [get]
+func unbanPeer(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ip := ctx.Query("ip")
+		if err := service.UnbanPeer(ip); err == nil {
+			ctx.JSON(http.StatusOK, NewMessageResponse("peer %s unbanned", ip))
+		} else {
+			ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+		}
+	}
+}
+
+// @Summary Check Files
+// @Description check the consistency of the torrents and download paths
+// @ID fsck
+// @Produce json
+// @Param fix query boolean false "remove the problematic files, except for unreferenced data and the files of the loaded torrents, which are only reported"
+// @Success 200 {object} bittorrent.FsckReport
+// @Router /fsck [get]
+func fsck(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		fix := ctx.DefaultQuery("fix", "false") == "true"
+		ctx.JSON(http.StatusOK, service.Fsck(fix))
+	}
+}
+
 // @Summary Add Magnet
 // @Description add magnet to service
 // @ID add-magnet
@@ -58,9 +146,12 @@ func resume(service *bittorrent.Service) gin.HandlerFunc {
 // @Param uri query string true "magnet URI"
 // @Param ignore_duplicate query boolean false "ignore if duplicate"
 // @Param download query boolean false "start downloading"
-// @Success 200 {object} NewTorrentResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Param web_seed query []string false "http(s) web seed url, can be repeated (ftp is not supported)" collectionFormat(multi)
+// @Param auto_select query string false "file to download once the metadata is received (largest_video, largest, all or none), ignored if the torrent was already added" default(none)
+// @Param buffer query boolean false "buffer the auto selected file"
+// @Success 200 {object} models.NewTorrentResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /add/magnet [get]
 func addMagnet(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -69,11 +160,26 @@ func addMagnet(service *bittorrent.Service) gin.HandlerFunc {
 			ctx.JSON(http.StatusBadRequest, NewErrorResponse("Invalid magnet provided"))
 			return
 		}
+		webSeeds, ok := webSeedsQuery(ctx)
+		if !ok {
+			return
+		}
+		autoSelect, ok := autoSelectQuery(ctx)
+		if !ok {
+			return
+		}
 		download := ctx.DefaultQuery("download", "false") == "true"
-		if infoHash, err := service.AddMagnet(magnet, download); err == nil ||
-			(err == bittorrent.DuplicateTorrentError &&
-				ctx.DefaultQuery("ignore_duplicate", "false") == "true") {
-			ctx.JSON(http.StatusOK, NewTorrentResponse{InfoHash: infoHash})
+		infoHash, err := service.AddMagnet(magnet, download)
+		// Auto select is not applied to duplicates, keeping their files priorities
+		added := err == nil
+		if added || (err == bittorrent.DuplicateTorrentError &&
+			ctx.DefaultQuery("ignore_duplicate", "false") == "true") {
+			if err = addWebSeeds(service, infoHash, webSeeds); err == nil && added {
+				err = autoSelect.apply(service, infoHash)
+			}
+		}
+		if err == nil {
+			ctx.JSON(http.StatusOK, models.NewTorrentResponse{InfoHash: infoHash})
 		} else {
 			ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
 		}
@@ -88,12 +194,23 @@ func addMagnet(service *bittorrent.Service) gin.HandlerFunc {
 // @Param torrent formData file true "torrent file"
 // @Param ignore_duplicate query boolean false "ignore if duplicate"
 // @Param download query boolean false "start downloading"
-// @Success 200 {object} NewTorrentResponse
-// @Failure 400 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Param web_seed query []string false "http(s) web seed url, can be repeated (ftp is not supported)" collectionFormat(multi)
+// @Param auto_select query string false "file to download (largest_video, largest, all or none), ignored if the torrent was already added" default(none)
+// @Param buffer query boolean false "buffer the auto selected file"
+// @Success 200 {object} models.NewTorrentResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /add/torrent [post]
 func addTorrent(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		webSeeds, ok := webSeedsQuery(ctx)
+		if !ok {
+			return
+		}
+		autoSelect, ok := autoSelectQuery(ctx)
+		if !ok {
+			return
+		}
 		if f, err := ctx.FormFile("torrent"); err == nil {
 			var err error
 			var infoHash string
@@ -103,11 +220,18 @@ func addTorrent(service *bittorrent.Service) gin.HandlerFunc {
 				data := make([]byte, f.Size)
 				if _, err = file.Read(data); err == nil {
 					download := ctx.DefaultQuery("download", "false") == "true"
-					if infoHash, err = service.AddTorrentData(data, download); err == nil ||
-						(err == bittorrent.DuplicateTorrentError &&
-							ctx.DefaultQuery("ignore_duplicate", "false") == "true") {
-						ctx.JSON(http.StatusOK, NewTorrentResponse{InfoHash: infoHash})
-						return
+					infoHash, err = service.AddTorrentData(data, download)
+					// Auto select is not applied to duplicates, keeping their files priorities
+					added := err == nil
+					if added || (err == bittorrent.DuplicateTorrentError &&
+						ctx.DefaultQuery("ignore_duplicate", "false") == "true") {
+						if err = addWebSeeds(service, infoHash, webSeeds); err == nil && added {
+							err = autoSelect.apply(service, infoHash)
+						}
+						if err == nil {
+							ctx.JSON(http.StatusOK, models.NewTorrentResponse{InfoHash: infoHash})
+							return
+						}
 					}
 				}
 			}
@@ -29,7 +29,8 @@ func getSettings(config *settings.Settings) gin.HandlerFunc {
 // @Param default body settings.Settings false "Settings to be set"
 // @Param reset query boolean false "reset torrents"
 // @Success 200 {object} settings.Settings
-// @Failure 500 {object} ErrorResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /settings/set [post]
 func setSettings(config *settings.Settings, service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -44,6 +45,10 @@ func setSettings(config *settings.Settings, service *bittorrent.Service) gin.Han
 			ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
 			return
 		}
+		if err := bittorrent.ValidateOverrides(newConfig.LibtorrentOverrides); err != nil {
+			ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+			return
+		}
 
 		setLogLevel(newConfig)
 		reset := ctx.DefaultQuery("reset", "false") == "true"
@@ -59,3 +64,27 @@ func setSettings(config *settings.Settings, service *bittorrent.Service) gin.Han
 		ctx.JSON(http.StatusOK, newConfig)
 	}
 }
+
+// @Summary Get settings sources
+// @Description get where each effective setting value came from (default, file, env or api)
+// @ID get-settings-sources
+// @Produce json
+// @Success 200 {object} object
+// @Router /settings/sources [get]
+func getSettingsSources(config *settings.Settings) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, config.Sources())
+	}
+}
+
+// @Summary Get libtorrent settings
+// @Description get the effective value of all libtorrent settings
+// @ID get-libtorrent-settings
+// @Produce json
+// @Success 200 {object} object
+// @Router /settings/libtorrent [get]
+func getLibtorrentSettings(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, service.LibtorrentSettings())
+	}
+}
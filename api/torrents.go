@@ -5,34 +5,20 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/i96751414/torrest/bittorrent"
+	"github.com/i96751414/torrest/models"
 )
 
-const (
-	startBufferPercent = 0.005
-	endBufferSize      = 10 * 1024 * 1024 // 10MB
-)
-
-type FileInfoResponse struct {
-	*bittorrent.FileInfo
-	Status *bittorrent.FileStatus `json:"status,omitempty"`
-}
-
-type TorrentInfoResponse struct {
-	*bittorrent.TorrentInfo
-	Status *bittorrent.TorrentStatus `json:"status,omitempty"`
-}
-
 // @Summary List Torrents
 // @Description list all torrents from service
 // @ID list-torrents
 // @Produce json
 // @Param status query boolean false "get torrents status"
-// @Success 200 {array} TorrentInfoResponse
+// @Success 200 {array} models.TorrentInfoResponse
 // @Router /torrents [get]
 func listTorrents(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		torrents := service.Torrents()
-		response := make([]TorrentInfoResponse, len(torrents))
+		response := make([]models.TorrentInfoResponse, len(torrents))
 		for i, torrent := range torrents {
 			response[i].TorrentInfo = torrent.GetInfo()
 		}
@@ -51,8 +37,8 @@ func listTorrents(service *bittorrent.Service) gin.HandlerFunc {
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
 // @Param delete query boolean false "delete files"
-// @Success 200 {object} MessageResponse
-// @Failure 404 {object} ErrorResponse
+// @Success 200 {object} models.MessageResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/remove [get]
 func removeTorrent(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -72,8 +58,8 @@ func removeTorrent(service *bittorrent.Service) gin.HandlerFunc {
 // @ID resume-torrent
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
-// @Success 200 {object} MessageResponse
-// @Failure 404 {object} ErrorResponse
+// @Success 200 {object} models.MessageResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/resume [get]
 func resumeTorrent(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -89,8 +75,8 @@ func resumeTorrent(service *bittorrent.Service) gin.HandlerFunc {
 // @ID pause-torrent
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
-// @Success 200 {object} MessageResponse
-// @Failure 404 {object} ErrorResponse
+// @Success 200 {object} models.MessageResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/pause [get]
 func pauseTorrent(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -106,8 +92,8 @@ func pauseTorrent(service *bittorrent.Service) gin.HandlerFunc {
 // @ID torrent-info
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
-// @Success 200 {object} bittorrent.TorrentInfo
-// @Failure 404 {object} ErrorResponse
+// @Success 200 {object} models.TorrentInfo
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/info [get]
 func torrentInfo(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -122,8 +108,8 @@ func torrentInfo(service *bittorrent.Service) gin.HandlerFunc {
 // @ID torrent-status
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
-// @Success 200 {object} bittorrent.TorrentStatus
-// @Failure 404 {object} ErrorResponse
+// @Success 200 {object} models.TorrentStatus
+// @Failure 404 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/status [get]
 func torrentStatus(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -139,15 +125,15 @@ func torrentStatus(service *bittorrent.Service) gin.HandlerFunc {
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
 // @Param status query boolean false "get files status"
-// @Success 200 {array} FileInfoResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Success 200 {array} models.FileInfoResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/files [get]
 func torrentFiles(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
 			if files, err := torrent.Files(); err == nil {
-				response := make([]FileInfoResponse, len(files))
+				response := make([]models.FileInfoResponse, len(files))
 				for i, file := range files {
 					response[i].FileInfo = file.Info()
 				}
@@ -169,14 +155,20 @@ func torrentFiles(service *bittorrent.Service) gin.HandlerFunc {
 // @ID download-torrent
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
-// @Success 200 {object} MessageResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Param priority query string false "download priority, either low, default, high, top or a value between 1 and 7" default(default)
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/download [get]
 func downloadTorrent(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
-			if err := torrent.SetPriority(bittorrent.DefaultPriority); err == nil {
+			priority, ok := downloadPriorityQuery(ctx)
+			if !ok {
+				return
+			}
+			if err := torrent.SetPriority(priority); err == nil {
 				ctx.JSON(http.StatusOK, NewMessageResponse("torrent '%s' is downloading", torrent.InfoHash()))
 			} else {
 				ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
@@ -190,9 +182,9 @@ func downloadTorrent(service *bittorrent.Service) gin.HandlerFunc {
 // @ID stop-torrent
 // @Produce json
 // @Param infoHash path string true "torrent info hash"
-// @Success 200 {object} MessageResponse
-// @Failure 404 {object} ErrorResponse
-// @Failure 500 {object} ErrorResponse
+// @Success 200 {object} models.MessageResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /torrents/{infoHash}/stop [get]
 func stopTorrent(service *bittorrent.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -215,3 +207,162 @@ func onGetTorrent(ctx *gin.Context, service *bittorrent.Service, f func(*bittorr
 		ctx.JSON(http.StatusNotFound, NewErrorResponse(err))
 	}
 }
+
+// @Summary Torrent Peers
+// @Description get the torrent peer list and the status of each peer. Only web seeds are listed, as libtorrent-go does not expose the swarm peers. Libtorrent only reports web seed errors, so failed is set while the last error is less than 2 minutes old, after which the web seed is assumed to have recovered, as libtorrent retries it. The last error is always reported.
+// @ID torrent-peers
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Success 200 {array} bittorrent.PeerInfo
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/peers [get]
+func torrentPeers(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			ctx.JSON(http.StatusOK, torrent.Peers())
+		})
+	}
+}
+
+// @Summary Connect Peer
+// @Description connect torrent to a specific peer
+// @ID connect-peer
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param address query string true "peer address, in the host:port format"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/connect [get]
+func connectPeer(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			address := ctx.Query("address")
+			if err := torrent.ConnectPeer(address); err == nil {
+				ctx.JSON(http.StatusOK, NewMessageResponse("connecting torrent '%s' to peer %s", torrent.InfoHash(), address))
+			} else {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+			}
+		})
+	}
+}
+
+// @Summary Get Torrent Bans
+// @Description get the peers banned from torrent
+// @ID torrent-bans
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Success 200 {array} string
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/bans [get]
+func torrentBans(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			ctx.JSON(http.StatusOK, torrent.Bans())
+		})
+	}
+}
+
+// @Summary Ban Torrent Peer
+// @Description ban peer ip address from torrent. Libtorrent only supports a session wide ip filter, so the peer is blocked from all torrents while the ban exists. The ban is discarded once the torrent is removed.
+// @ID ban-torrent-peer
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param ip query string true "peer ip address"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/ban [get]
+func banTorrentPeer(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			ip := ctx.Query("ip")
+			if err := torrent.BanPeer(ip); err == nil {
+				ctx.JSON(http.StatusOK, NewMessageResponse("peer %s banned from torrent '%s'", ip, torrent.InfoHash()))
+			} else {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+			}
+		})
+	}
+}
+
+// @Summary Unban Torrent Peer
+// @Description remove torrent ban of peer ip address
+// @ID unban-torrent-peer
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param ip query string true "peer ip address"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/unban [get]
+func unbanTorrentPeer(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			ip := ctx.Query("ip")
+			if err := torrent.UnbanPeer(ip); err == nil {
+				ctx.JSON(http.StatusOK, NewMessageResponse("peer %s unbanned from torrent '%s'", ip, torrent.InfoHash()))
+			} else {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+			}
+		})
+	}
+}
+
+// @Summary Get Torrent Metadata
+// @Description get detailed torrent metadata, such as comment, creator, pieces, trackers and web seeds
+// @ID torrent-metadata
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Success 200 {object} models.TorrentMetadata
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/metadata [get]
+func torrentMetadata(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			ctx.JSON(http.StatusOK, torrent.GetMetadata())
+		})
+	}
+}
+
+// @Summary Get Torrent File
+// @Description download the torrent file, which is only available after receiving the torrent metadata
+// @ID torrent-file
+// @Produce application/x-bittorrent
+// @Param infoHash path string true "torrent info hash"
+// @Success 200 {file} file
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/torrent [get]
+func torrentFile(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			if data, err := torrent.TorrentFile(); err == nil {
+				ctx.Header("Content-Disposition", contentDisposition("attachment", torrent.GetInfo().Name+".torrent"))
+				ctx.Data(http.StatusOK, "application/x-bittorrent", data)
+			} else if err == bittorrent.NoMetadataError {
+				ctx.JSON(http.StatusNotFound, NewErrorResponse(err))
+			} else {
+				ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
+			}
+		})
+	}
+}
+
+// @Summary Get Torrent Magnet
+// @Description generate the torrent magnet uri
+// @ID torrent-magnet
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param trackers query boolean false "include trackers in the magnet uri" default(true)
+// @Success 200 {object} models.MagnetResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/magnet [get]
+func torrentMagnet(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			includeTrackers := ctx.DefaultQuery("trackers", "true") == "true"
+			ctx.JSON(http.StatusOK, models.MagnetResponse{Magnet: torrent.MagnetUri(includeTrackers)})
+		})
+	}
+}
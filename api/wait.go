@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/i96751414/torrest/bittorrent"
+	"github.com/i96751414/torrest/models"
+)
+
+const (
+	defaultWaitTimeout = 30
+	maxWaitTimeout     = 300
+)
+
+type WaitTorrentResponse struct {
+	Done   bool                  `json:"done" example:"true"`
+	Status *models.TorrentStatus `json:"status"`
+}
+
+type WaitFileResponse struct {
+	Done   bool               `json:"done" example:"true"`
+	Status *models.FileStatus `json:"status"`
+}
+
+// Can produce 400 (StatusBadRequest) http error
+func timeoutQuery(ctx *gin.Context, defaultTimeout, maxTimeout int) (time.Duration, bool) {
+	timeout, err := strconv.Atoi(ctx.DefaultQuery("timeout", strconv.Itoa(defaultTimeout)))
+	if err != nil || timeout <= 0 || timeout > maxTimeout {
+		ctx.JSON(http.StatusBadRequest, NewErrorResponse("'timeout' must be an integer between 1 and "+strconv.Itoa(maxTimeout)))
+		return 0, false
+	}
+	return time.Duration(timeout) * time.Second, true
+}
+
+// onWait waits with the timeout provided in the request, calling f with
+// whether the condition was met before the timeout.
+// Can produce 400 (StatusBadRequest), 404 (StatusNotFound) and
+// 500 (StatusInternalServerError) http errors
+func onWait(ctx *gin.Context, wait func(context.Context) error, f func(done bool)) {
+	timeout, ok := timeoutQuery(ctx, defaultWaitTimeout, maxWaitTimeout)
+	if !ok {
+		return
+	}
+	waitCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+	defer cancel()
+
+	switch err := wait(waitCtx); err {
+	case nil:
+		f(true)
+	case bittorrent.TimeoutError:
+		f(false)
+	case bittorrent.TorrentClosedError:
+		ctx.JSON(http.StatusNotFound, NewErrorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, NewErrorResponse(err))
+	}
+}
+
+// @Summary Wait For Metadata
+// @Description wait until the torrent metadata is received, returning the torrent status
+// @ID wait-metadata
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param timeout query integer false "maximum time to wait, in seconds (up to 300)" default(30)
+// @Success 200 {object} WaitTorrentResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/wait/metadata [get]
+func waitMetadata(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			onWait(ctx, torrent.WaitForMetadata, func(done bool) {
+				ctx.JSON(http.StatusOK, WaitTorrentResponse{Done: done, Status: torrent.GetStatus()})
+			})
+		})
+	}
+}
+
+// @Summary Wait For State Change
+// @Description wait until the torrent state changes, returning the torrent status
+// @ID wait-state
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param from query string false "state to change from, either its name or value (defaults to the current state)"
+// @Param timeout query integer false "maximum time to wait, in seconds (up to 300)" default(30)
+// @Success 200 {object} WaitTorrentResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/wait/state [get]
+func waitState(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			from := torrent.GetState()
+			if value, ok := ctx.GetQuery("from"); ok {
+				var err error
+				if from, err = models.ParseLTStatus(value); err != nil {
+					ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+					return
+				}
+			}
+
+			onWait(ctx, func(waitCtx context.Context) error {
+				return torrent.WaitForStateChange(waitCtx, from)
+			}, func(done bool) {
+				ctx.JSON(http.StatusOK, WaitTorrentResponse{Done: done, Status: torrent.GetStatus()})
+			})
+		})
+	}
+}
+
+// @Summary Wait For Buffering
+// @Description wait until the file buffering finishes, returning the file status
+// @ID wait-buffer
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param file path integer true "file id"
+// @Param timeout query integer false "maximum time to wait, in seconds (up to 300)" default(30)
+// @Success 200 {object} WaitFileResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/files/{file}/wait/buffer [get]
+func waitBuffer(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetFile(ctx, service, func(file *bittorrent.File) {
+			onWait(ctx, file.WaitForBuffering, func(done bool) {
+				ctx.JSON(http.StatusOK, WaitFileResponse{Done: done, Status: file.Status()})
+			})
+		})
+	}
+}
+
+// @Summary Wait For Completion
+// @Description wait until the file is completely downloaded, returning the file status
+// @ID wait-complete
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param file path integer true "file id"
+// @Param timeout query integer false "maximum time to wait, in seconds (up to 300)" default(30)
+// @Success 200 {object} WaitFileResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/files/{file}/wait/complete [get]
+func waitComplete(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetFile(ctx, service, func(file *bittorrent.File) {
+			onWait(ctx, file.WaitForCompletion, func(done bool) {
+				ctx.JSON(http.StatusOK, WaitFileResponse{Done: done, Status: file.Status()})
+			})
+		})
+	}
+}
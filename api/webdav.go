@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/i96751414/torrest/bittorrent"
+	"github.com/i96751414/torrest/util"
+	"golang.org/x/net/webdav"
+)
+
+const webDAVPrefix = "/webdav"
+
+var (
+	webDAVMethods = []string{
+		http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete,
+		"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK",
+	}
+	torrentDirRegex = regexp.MustCompile(`\[([0-9a-f]{40})]$`)
+)
+
+// webDAVRoutes registers a read-only WebDAV server exposing every torrent as
+// a directory containing its files
+func webDAVRoutes(r *gin.Engine, service *bittorrent.Service) {
+	handler := gin.WrapH(&webdav.Handler{
+		Prefix:     webDAVPrefix,
+		FileSystem: &torrentsFileSystem{service: service},
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				log.Debugf("WebDAV %s %s: %s", r.Method, r.URL.Path, err)
+			}
+		},
+	})
+
+	for _, method := range webDAVMethods {
+		r.Handle(method, webDAVPrefix, handler)
+		r.Handle(method, webDAVPrefix+"/*path", handler)
+	}
+}
+
+// torrentsFileSystem implements a read-only webdav.FileSystem where the root
+// directory lists the torrents and each torrent directory lists its files
+type torrentsFileSystem struct {
+	service *bittorrent.Service
+}
+
+func (fs *torrentsFileSystem) Mkdir(_ context.Context, _ string, _ os.FileMode) error {
+	return os.ErrPermission
+}
+
+func (fs *torrentsFileSystem) RemoveAll(_ context.Context, _ string) error {
+	return os.ErrPermission
+}
+
+func (fs *torrentsFileSystem) Rename(_ context.Context, _, _ string) error {
+	return os.ErrPermission
+}
+
+func (fs *torrentsFileSystem) Stat(_ context.Context, name string) (os.FileInfo, error) {
+	n, err := fs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return n.info, nil
+}
+
+func (fs *torrentsFileSystem) OpenFile(ctx context.Context, name string, flag int, _ os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, os.ErrPermission
+	}
+	n, err := fs.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &davFile{info: n.info}
+	if n.file != nil {
+		f.reader = n.file.NewReaderContext(ctx)
+	} else if f.children, err = fs.readDir(n); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type davNode struct {
+	info    *davFileInfo
+	torrent *bittorrent.Torrent
+	file    *bittorrent.File
+	dir     string
+}
+
+func (fs *torrentsFileSystem) resolve(name string) (*davNode, error) {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return &davNode{info: newDirInfo("/")}, nil
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	match := torrentDirRegex.FindStringSubmatch(parts[0])
+	if match == nil {
+		return nil, os.ErrNotExist
+	}
+	torrent, err := fs.service.GetTorrent(match[1])
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	if len(parts) == 1 {
+		return &davNode{info: newDirInfo(torrentDirName(torrent)), torrent: torrent}, nil
+	}
+
+	// Files can only be resolved after receiving the metadata
+	files, _ := torrent.Files()
+	dirPrefix := parts[1] + "/"
+	for _, file := range files {
+		filePath := torrentFilePath(torrent, file)
+		if filePath == parts[1] {
+			return &davNode{info: newFileInfo(file), torrent: torrent, file: file}, nil
+		} else if strings.HasPrefix(filePath, dirPrefix) {
+			return &davNode{info: newDirInfo(path.Base(parts[1])), torrent: torrent, dir: parts[1]}, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+func (fs *torrentsFileSystem) readDir(n *davNode) ([]os.FileInfo, error) {
+	var children []os.FileInfo
+	if n.torrent == nil {
+		for _, torrent := range fs.service.Torrents() {
+			children = append(children, newDirInfo(torrentDirName(torrent)))
+		}
+		return children, nil
+	}
+
+	// A torrent without metadata is listed as an empty directory
+	files, _ := n.torrent.Files()
+	entries, _ := listDirectory(files, n.dir, func(file *bittorrent.File) string {
+		return torrentFilePath(n.torrent, file)
+	})
+	for _, entry := range entries {
+		if entry.file != nil {
+			children = append(children, newFileInfo(entry.file))
+		} else {
+			children = append(children, newDirInfo(entry.name))
+		}
+	}
+	return children, nil
+}
+
+// torrentDirName returns the directory name of the torrent, which is composed
+// by the torrent name and its info hash
+func torrentDirName(torrent *bittorrent.Torrent) string {
+	name := strings.Replace(torrent.GetInfo().Name, "/", "_", -1)
+	return name + " [" + torrent.InfoHash() + "]"
+}
+
+// torrentFilePath returns the slash separated path of the file relative to
+// the torrent directory
+func torrentFilePath(torrent *bittorrent.Torrent, file *bittorrent.File) string {
+	filePath := strings.Replace(file.Path(), "\\", "/", -1)
+	return strings.TrimPrefix(filePath, torrent.GetInfo().Name+"/")
+}
+
+type davFileInfo struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func newDirInfo(name string) *davFileInfo {
+	return &davFileInfo{name: name, isDir: true}
+}
+
+func newFileInfo(file *bittorrent.File) *davFileInfo {
+	return &davFileInfo{name: file.Name(), size: file.Length()}
+}
+
+func (i *davFileInfo) Name() string {
+	return i.name
+}
+
+func (i *davFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i *davFileInfo) Mode() os.FileMode {
+	if i.isDir {
+		return os.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (i *davFileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i *davFileInfo) IsDir() bool {
+	return i.isDir
+}
+
+func (i *davFileInfo) Sys() interface{} {
+	return nil
+}
+
+// ContentType implements webdav.ContentTyper so that listing directories
+// never reads (and therefore never waits for) the files data
+func (i *davFileInfo) ContentType(_ context.Context) (string, error) {
+	if contentType := util.MimeTypeByName(i.name); contentType != "" {
+		return contentType, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// davFile implements webdav.File. Files are backed by a torrent file reader
+// and directories by the list of their children.
+type davFile struct {
+	info     *davFileInfo
+	reader   io.ReadSeekCloser
+	children []os.FileInfo
+	dirPos   int
+}
+
+func (f *davFile) Close() error {
+	if f.reader != nil {
+		return f.reader.Close()
+	}
+	return nil
+}
+
+func (f *davFile) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		return 0, os.ErrInvalid
+	}
+	return f.reader.Read(p)
+}
+
+func (f *davFile) Seek(offset int64, whence int) (int64, error) {
+	if f.reader == nil {
+		return 0, os.ErrInvalid
+	}
+	return f.reader.Seek(offset, whence)
+}
+
+func (f *davFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.info.isDir {
+		return nil, os.ErrInvalid
+	}
+	remaining := f.children[f.dirPos:]
+	if count <= 0 {
+		f.dirPos = len(f.children)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(remaining) {
+		count = len(remaining)
+	}
+	f.dirPos += count
+	return remaining[:count], nil
+}
+
+func (f *davFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *davFile) Write(_ []byte) (int, error) {
+	return 0, os.ErrPermission
+}
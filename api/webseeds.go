@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/i96751414/torrest/bittorrent"
+)
+
+// Can produce 400 (StatusBadRequest) http error
+func webSeedsQuery(ctx *gin.Context) ([]string, bool) {
+	webSeeds := ctx.QueryArray("web_seed")
+	for _, webSeed := range webSeeds {
+		if err := bittorrent.ValidateWebSeed(webSeed); err != nil {
+			ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+			return nil, false
+		}
+	}
+	return webSeeds, true
+}
+
+func addWebSeeds(service *bittorrent.Service, infoHash string, webSeeds []string) error {
+	if len(webSeeds) == 0 {
+		return nil
+	}
+	torrent, err := service.GetTorrent(infoHash)
+	if err != nil {
+		return err
+	}
+	for _, webSeed := range webSeeds {
+		if err := torrent.AddWebSeed(webSeed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// @Summary Add Url Seed
+// @Description add a http(s) web seed (BEP 19) to the torrent. FTP web seeds are not supported by libtorrent and are rejected.
+// @ID add-url-seed
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param url query string true "http(s) web seed url"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/add_url_seed [get]
+func addUrlSeed(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			webSeed := ctx.Query("url")
+			if err := torrent.AddWebSeed(webSeed); err == nil {
+				ctx.JSON(http.StatusOK, NewMessageResponse("added web seed %s to torrent '%s'", webSeed, torrent.InfoHash()))
+			} else {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+			}
+		})
+	}
+}
+
+// @Summary Remove Url Seed
+// @Description remove a web seed from the torrent
+// @ID remove-url-seed
+// @Produce json
+// @Param infoHash path string true "torrent info hash"
+// @Param url query string true "web seed url"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /torrents/{infoHash}/remove_url_seed [get]
+func removeUrlSeed(service *bittorrent.Service) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		onGetTorrent(ctx, service, func(torrent *bittorrent.Torrent) {
+			webSeed := ctx.Query("url")
+			if err := torrent.RemoveWebSeed(webSeed); err == nil {
+				ctx.JSON(http.StatusOK, NewMessageResponse("removed web seed %s from torrent '%s'", webSeed, torrent.InfoHash()))
+			} else {
+				ctx.JSON(http.StatusBadRequest, NewErrorResponse(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package bittorrent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/i96751414/torrest/util"
+)
+
+const autoSelectFileName = "auto_select.gob"
+
+type AutoSelectMode string
+
+const (
+	AutoSelectNone         AutoSelectMode = "none"
+	AutoSelectLargestVideo AutoSelectMode = "largest_video"
+	AutoSelectLargest      AutoSelectMode = "largest"
+	AutoSelectAll          AutoSelectMode = "all"
+)
+
+// ParseAutoSelectMode parses the auto select mode, defaulting to none if empty
+func ParseAutoSelectMode(value string) (AutoSelectMode, error) {
+	switch mode := AutoSelectMode(value); mode {
+	case "":
+		return AutoSelectNone, nil
+	case AutoSelectNone, AutoSelectLargestVideo, AutoSelectLargest, AutoSelectAll:
+		return mode, nil
+	default:
+		return "", InvalidAutoSelectError
+	}
+}
+
+// autoSelection is the auto select request of a torrent, which is applied
+// once the metadata is received
+type autoSelection struct {
+	Mode   AutoSelectMode
+	Buffer bool
+	// Id of the selected file, or -1 if none
+	File int
+	Done bool
+}
+
+type autoSelections struct {
+	mu       sync.Mutex
+	torrents map[string]*autoSelection
+}
+
+func newAutoSelections() *autoSelections {
+	return &autoSelections{torrents: make(map[string]*autoSelection)}
+}
+
+func (s *Service) autoSelectFilePath() string {
+	return filepath.Join(s.config.TorrentsPath, autoSelectFileName)
+}
+
+// loadAutoSelections loads the persisted auto selections, discarding the ones
+// belonging to torrents which are no longer in the service, and applies the
+// pending ones whose metadata is already available
+func (s *Service) loadAutoSelections() {
+	torrents := make(map[string]*autoSelection)
+	if err := readGobData(s.autoSelectFilePath(), &torrents); err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("Failed reading auto selections: %s", err)
+		}
+		return
+	}
+
+	s.autoSelect.mu.Lock()
+	defer s.autoSelect.mu.Unlock()
+	s.autoSelect.torrents = torrents
+	for infoHash, selection := range torrents {
+		if _, t, err := s.getTorrent(infoHash); err != nil {
+			delete(torrents, infoHash)
+		} else if !selection.Done && t.hasMetadata {
+			s.applyAutoSelection(t, selection)
+		}
+	}
+	s.saveAutoSelections()
+}
+
+// saveAutoSelections persists the auto selections. Must be called with the
+// auto selections lock held.
+func (s *Service) saveAutoSelections() {
+	if err := saveGobData(s.autoSelectFilePath(), s.autoSelect.torrents, 0644); err != nil {
+		log.Errorf("Failed saving auto selections: %s", err)
+	}
+}
+
+// removeTorrentAutoSelection discards the auto selection of a removed torrent
+func (s *Service) removeTorrentAutoSelection(infoHash string) {
+	s.autoSelect.mu.Lock()
+	defer s.autoSelect.mu.Unlock()
+	if _, ok := s.autoSelect.torrents[infoHash]; ok {
+		delete(s.autoSelect.torrents, infoHash)
+		s.saveAutoSelections()
+	}
+}
+
+// onTorrentMetadata applies the pending auto selection of the torrent, if any
+func (s *Service) onTorrentMetadata(t *Torrent) {
+	s.autoSelect.mu.Lock()
+	defer s.autoSelect.mu.Unlock()
+	if selection, ok := s.autoSelect.torrents[t.infoHash]; ok && !selection.Done {
+		s.applyAutoSelection(t, selection)
+		s.saveAutoSelections()
+	}
+}
+
+// selectedFile returns the id of the auto selected file, or -1 if none
+func (s *Service) selectedFile(infoHash string) int {
+	s.autoSelect.mu.Lock()
+	defer s.autoSelect.mu.Unlock()
+	if selection, ok := s.autoSelect.torrents[infoHash]; ok {
+		return selection.File
+	}
+	return -1
+}
+
+// applyAutoSelection picks the file according to the selection mode, sets it
+// to download and starts buffering it if requested. Must be called with the
+// auto selections lock held.
+func (s *Service) applyAutoSelection(t *Torrent, selection *autoSelection) {
+	t.mu.RLock()
+	files := t.files
+	t.mu.RUnlock()
+
+	var selected *File
+	for _, f := range files {
+		if selection.Mode == AutoSelectLargestVideo && !strings.HasPrefix(util.MimeTypeByName(f.Name()), "video/") {
+			continue
+		}
+		if selected == nil || f.Length() > selected.Length() {
+			selected = f
+		}
+	}
+
+	selection.Done = true
+	selection.File = -1
+	if selected == nil {
+		log.Warningf("No file matching %s found on torrent %s", selection.Mode, t.infoHash)
+		return
+	}
+	selection.File = selected.Id()
+	log.Infof("Auto selected file %s:%d (%s)", t.infoHash, selected.Id(), selected.Name())
+
+	for _, f := range files {
+		if f == selected || selection.Mode == AutoSelectAll {
+			f.SetPriority(DefaultPriority)
+		} else {
+			f.SetPriority(DontDownloadPriority)
+		}
+	}
+	if selection.Buffer {
+		selected.Buffer(selected.BufferSizes(s.config.BufferSize))
+	}
+}
+
+// AutoSelect requests the torrent main file to be selected for download once
+// the metadata is available, or immediately if it already is. With
+// AutoSelectAll all files are downloaded, but only the largest one is
+// selected and buffered. The request is persisted, so it is applied even if
+// the service restarts before receiving the metadata.
+func (t *Torrent) AutoSelect(mode AutoSelectMode, buffer bool) {
+	s := t.service
+	s.autoSelect.mu.Lock()
+	defer s.autoSelect.mu.Unlock()
+
+	if mode == AutoSelectNone {
+		delete(s.autoSelect.torrents, t.infoHash)
+	} else {
+		selection := &autoSelection{Mode: mode, Buffer: buffer, File: -1}
+		s.autoSelect.torrents[t.infoHash] = selection
+		if t.HasMetadata() {
+			s.applyAutoSelection(t, selection)
+		}
+	}
+	s.saveAutoSelections()
+}
@@ -0,0 +1,144 @@
+package bittorrent
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+)
+
+const bansFileName = "bans.gob"
+
+// Bans holds the banned peers ip addresses, both global and per torrent.
+// Libtorrent only supports a session wide ip filter, so torrent bans are
+// enforced the same way as global ones, but are discarded together with the
+// torrent.
+type Bans struct {
+	Global   []string            `json:"global"`
+	Torrents map[string][]string `json:"torrents"`
+}
+
+func (s *Service) bansFilePath() string {
+	return filepath.Join(s.config.TorrentsPath, bansFileName)
+}
+
+// loadBans loads the persisted bans, discarding the ones belonging to torrents
+// which are no longer in the service, and applies the ip filter, so the peers
+// are blocked before the blocklist sources are loaded
+func (s *Service) loadBans() {
+	bans := Bans{}
+	if err := readGobData(s.bansFilePath(), &bans); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Failed reading bans: %s", err)
+	}
+	for infoHash := range bans.Torrents {
+		if _, _, err := s.getTorrent(infoHash); err != nil {
+			delete(bans.Torrents, infoHash)
+		}
+	}
+	if bans.Torrents == nil {
+		bans.Torrents = make(map[string][]string)
+	}
+
+	s.blocklist.mu.Lock()
+	s.blocklist.bans = bans
+	s.applyIpFilter()
+	s.blocklist.mu.Unlock()
+}
+
+// saveBans persists the bans. Must be called with the blocklist lock held.
+func (s *Service) saveBans() {
+	if err := saveGobData(s.bansFilePath(), s.blocklist.bans, 0644); err != nil {
+		log.Errorf("Failed saving bans: %s", err)
+	}
+}
+
+// Bans returns a copy of the banned peers
+func (s *Service) Bans() *Bans {
+	s.blocklist.mu.RLock()
+	defer s.blocklist.mu.RUnlock()
+	bans := &Bans{
+		Global:   append([]string{}, s.blocklist.bans.Global...),
+		Torrents: make(map[string][]string, len(s.blocklist.bans.Torrents)),
+	}
+	for infoHash, ips := range s.blocklist.bans.Torrents {
+		bans.Torrents[infoHash] = append([]string{}, ips...)
+	}
+	return bans
+}
+
+// BanPeer bans the peer ip address from all torrents
+func (s *Service) BanPeer(ip string) error {
+	return s.banPeer("", ip, true)
+}
+
+// UnbanPeer removes the global ban of the peer ip address
+func (s *Service) UnbanPeer(ip string) error {
+	return s.banPeer("", ip, false)
+}
+
+// banPeer adds or removes the ip from the global bans, or from the torrent
+// bans if infoHash is provided, persisting the changes and updating the
+// session ip filter
+func (s *Service) banPeer(infoHash, ip string, ban bool) error {
+	parsedIp := net.ParseIP(ip)
+	if parsedIp == nil {
+		return InvalidIpError
+	}
+	ip = parsedIp.String()
+
+	s.blocklist.mu.Lock()
+	defer s.blocklist.mu.Unlock()
+
+	ips := s.blocklist.bans.Global
+	if infoHash != "" {
+		ips = s.blocklist.bans.Torrents[infoHash]
+	}
+
+	index := -1
+	for i, bannedIp := range ips {
+		if bannedIp == ip {
+			index = i
+			break
+		}
+	}
+	if ban == (index >= 0) {
+		// nothing to update
+		return nil
+	}
+
+	if ban {
+		log.Infof("Banning peer %s", ip)
+		ips = append(ips, ip)
+	} else {
+		log.Infof("Unbanning peer %s", ip)
+		ips = append(ips[:index], ips[index+1:]...)
+	}
+
+	if infoHash == "" {
+		s.blocklist.bans.Global = ips
+	} else if len(ips) == 0 {
+		delete(s.blocklist.bans.Torrents, infoHash)
+	} else {
+		s.blocklist.bans.Torrents[infoHash] = ips
+	}
+
+	s.saveBans()
+	s.applyIpFilter()
+	return nil
+}
+
+func (s *Service) torrentBans(infoHash string) []string {
+	s.blocklist.mu.RLock()
+	defer s.blocklist.mu.RUnlock()
+	return append([]string{}, s.blocklist.bans.Torrents[infoHash]...)
+}
+
+// removeTorrentBans discards the bans of a removed torrent
+func (s *Service) removeTorrentBans(infoHash string) {
+	s.blocklist.mu.Lock()
+	defer s.blocklist.mu.Unlock()
+	if _, ok := s.blocklist.bans.Torrents[infoHash]; ok {
+		delete(s.blocklist.bans.Torrents, infoHash)
+		s.saveBans()
+		s.applyIpFilter()
+	}
+}
@@ -0,0 +1,308 @@
+package bittorrent
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/i96751414/libtorrent-go"
+)
+
+const blocklistDownloadTimeout = 2 * time.Minute
+
+type BlocklistStatus struct {
+	Sources      []string  `json:"sources"`
+	Rules        int       `json:"rules" example:"250000"`
+	InvalidLines int       `json:"invalid_lines" example:"0"`
+	LastLoad     time.Time `json:"last_load"`
+	LastError    string    `json:"last_error" example:""`
+	BlockedPeers int64     `json:"blocked_peers" example:"10"`
+}
+
+type blocklist struct {
+	mu           *sync.RWMutex
+	reload       chan interface{}
+	status       BlocklistStatus
+	ranges       []ipRange
+	bans         Bans
+	blockedPeers int64
+}
+
+func newBlocklist() *blocklist {
+	return &blocklist{
+		mu:     &sync.RWMutex{},
+		reload: make(chan interface{}, 1),
+		bans:   Bans{Torrents: make(map[string][]string)},
+	}
+}
+
+type ipRange struct {
+	first net.IP
+	last  net.IP
+}
+
+func (s *Service) blocklistLoop() {
+	defer s.wg.Done()
+	s.loadBlocklist()
+	for {
+		// A non positive refresh interval disables the scheduled refresh
+		var refresh <-chan time.Time
+		if s.config.BlocklistRefresh > 0 && len(s.config.BlocklistSources) > 0 {
+			refresh = time.After(s.config.BlocklistRefresh * time.Second)
+		}
+
+		select {
+		case <-s.closing:
+			return
+		case <-refresh:
+			s.loadBlocklist()
+		case <-s.blocklist.reload:
+			s.loadBlocklist()
+		}
+	}
+}
+
+// ReloadBlocklist schedules the reload of the blocklist sources
+func (s *Service) ReloadBlocklist() {
+	select {
+	case s.blocklist.reload <- nil:
+	default:
+		// reload already scheduled
+	}
+}
+
+// BlocklistStatus returns the status of the last blocklist load
+func (s *Service) BlocklistStatus() *BlocklistStatus {
+	s.blocklist.mu.RLock()
+	defer s.blocklist.mu.RUnlock()
+	status := s.blocklist.status
+	status.BlockedPeers = s.blocklist.blockedPeers
+	return &status
+}
+
+// onPeerBlocked counts the peers blocked by the ip filter. Peers blocked for
+// other reasons (port filter, disabled protocols, ...) are not counted.
+func (s *Service) onPeerBlocked(alert libtorrent.PeerBlockedAlert) {
+	if alert.GetReason() != int(libtorrent.PeerBlockedAlertIpFilter) {
+		return
+	}
+	s.blocklist.mu.Lock()
+	s.blocklist.blockedPeers++
+	s.blocklist.mu.Unlock()
+}
+
+func (s *Service) loadBlocklist() {
+	sources := append([]string(nil), s.config.BlocklistSources...)
+	status := BlocklistStatus{Sources: sources, LastLoad: time.Now()}
+
+	var ranges []ipRange
+	var errs []string
+	for _, source := range sources {
+		log.Infof("Loading blocklist from %s", source)
+		sourceRanges, invalidLines, err := readBlocklistSource(source)
+		if err != nil {
+			log.Errorf("Failed loading blocklist from %s: %s", source, err)
+			errs = append(errs, source+": "+err.Error())
+			continue
+		}
+		if invalidLines > 0 {
+			log.Warningf("Ignored %d invalid lines from blocklist %s", invalidLines, source)
+		}
+		ranges = append(ranges, sourceRanges...)
+		status.InvalidLines += invalidLines
+	}
+	status.LastError = strings.Join(errs, "; ")
+
+	if len(sources) > 0 && len(errs) == len(sources) {
+		// Keep the previous filter instead of leaving the session unprotected
+		log.Warning("Unable to load any blocklist source, keeping previous rules")
+		s.blocklist.mu.Lock()
+		status.Rules = s.blocklist.status.Rules
+		s.blocklist.status = status
+		s.blocklist.mu.Unlock()
+		return
+	}
+
+	status.Rules = len(ranges)
+	log.Infof("Blocklist loaded with %d rules", status.Rules)
+
+	s.blocklist.mu.Lock()
+	s.blocklist.status = status
+	s.blocklist.ranges = ranges
+	s.applyIpFilter()
+	s.blocklist.mu.Unlock()
+}
+
+// applyIpFilter sets the session ip filter with both the blocklist rules and
+// the banned peers. Must be called with the blocklist lock held.
+func (s *Service) applyIpFilter() {
+	filter := libtorrent.NewIpFilter()
+	defer libtorrent.DeleteIpFilter(filter)
+	addRule := func(first, last string) {
+		firstAddress := libtorrent.MakeAddress(first)
+		lastAddress := libtorrent.MakeAddress(last)
+		filter.AddRule(firstAddress, lastAddress, libtorrent.IpFilterBlocked)
+		libtorrent.DeleteAddress(firstAddress)
+		libtorrent.DeleteAddress(lastAddress)
+	}
+
+	for _, r := range s.blocklist.ranges {
+		addRule(r.first.String(), r.last.String())
+	}
+	for _, ip := range s.blocklist.bans.Global {
+		addRule(ip, ip)
+	}
+	for _, ips := range s.blocklist.bans.Torrents {
+		for _, ip := range ips {
+			addRule(ip, ip)
+		}
+	}
+	s.session.SetIpFilter(filter)
+}
+
+// readBlocklistSource reads the blocklist from either a local file or an
+// http(s) url, decompressing it if gzipped
+func readBlocklistSource(source string) ([]ipRange, int, error) {
+	var r io.ReadCloser
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		client := &http.Client{Timeout: blocklistDownloadTimeout}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, 0, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, 0, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, 0, err
+		}
+		r = f
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			log.Errorf("Failed closing blocklist %s: %s", source, err)
+		}
+	}()
+
+	reader := bufio.NewReader(r)
+	if magic, err := reader.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		return parseBlocklist(gz)
+	}
+	return parseBlocklist(reader)
+}
+
+// parseBlocklist parses eMule DAT, PeerGuardian P2P and CIDR blocklists. The
+// format is detected for each line, so files can even mix them.
+func parseBlocklist(r io.Reader) (ranges []ipRange, invalidLines int, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if rule, blocked, e := parseBlocklistLine(line); e != nil {
+			invalidLines++
+		} else if blocked {
+			ranges = append(ranges, rule)
+		}
+	}
+	err = scanner.Err()
+	if err == nil && len(ranges) == 0 && invalidLines > 0 {
+		err = errors.New("no valid rules found")
+	}
+	return
+}
+
+func parseBlocklistLine(line string) (r ipRange, blocked bool, err error) {
+	// CIDR, e.g. 1.2.4.0/24
+	if _, network, e := net.ParseCIDR(line); e == nil {
+		r.first = network.IP
+		r.last = make(net.IP, len(network.IP))
+		for i := range network.IP {
+			r.last[i] = network.IP[i] | ^network.Mask[i]
+		}
+		return r, true, nil
+	}
+
+	rangeString := line
+	if fields := strings.Split(line, ","); len(fields) >= 2 {
+		// eMule DAT, e.g. 001.002.004.000 - 001.002.004.255 , 000 , Description
+		// Access levels of 128 or more are allowed. Lines without a numeric
+		// level are not DAT, as P2P descriptions may also contain commas.
+		if level, e := strconv.Atoi(strings.TrimSpace(fields[1])); e == nil {
+			if level >= 128 {
+				return r, false, nil
+			}
+			rangeString = fields[0]
+		}
+	}
+	if i := strings.LastIndex(line, ":"); rangeString == line && i >= 0 && strings.Contains(line[i:], "-") {
+		// PeerGuardian P2P, e.g. Apple Computer, Inc:17.0.0.0-17.255.255.255
+		rangeString = line[i+1:]
+	}
+
+	parts := strings.Split(rangeString, "-")
+	if r.first, err = parseBlocklistIP(parts[0]); err != nil {
+		return
+	}
+	switch len(parts) {
+	case 1:
+		r.last = r.first
+	case 2:
+		if r.last, err = parseBlocklistIP(parts[1]); err != nil {
+			return
+		}
+	default:
+		return r, false, errors.New("invalid range")
+	}
+	if (r.first.To4() == nil) != (r.last.To4() == nil) || bytes.Compare(r.first.To16(), r.last.To16()) > 0 {
+		return r, false, errors.New("invalid range")
+	}
+	return r, true, nil
+}
+
+// parseBlocklistIP parses IP addresses, accepting zero padded IPv4 addresses
+func parseBlocklistIP(s string) (net.IP, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, ":") {
+		if ip := net.ParseIP(s); ip != nil {
+			return ip, nil
+		}
+		return nil, errors.New("invalid ip")
+	}
+
+	octets := strings.Split(s, ".")
+	if len(octets) != 4 {
+		return nil, errors.New("invalid ip")
+	}
+	ip := make(net.IP, 4)
+	for i, octet := range octets {
+		v, err := strconv.Atoi(octet)
+		if err != nil || v < 0 || v > 255 {
+			return nil, errors.New("invalid ip")
+		}
+		ip[i] = byte(v)
+	}
+	return ip, nil
+}
@@ -0,0 +1,54 @@
+package bittorrent
+
+import "testing"
+
+func TestParseBlocklistLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		first   string
+		last    string
+		blocked bool
+		err     bool
+	}{
+		{name: "single ip", line: "1.2.3.4", first: "1.2.3.4", last: "1.2.3.4", blocked: true},
+		{name: "ipv4 cidr", line: "1.2.4.0/24", first: "1.2.4.0", last: "1.2.4.255", blocked: true},
+		{name: "ipv6 cidr", line: "2001:db8::/32", first: "2001:db8::", last: "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", blocked: true},
+		{name: "plain range", line: "10.0.0.1 - 10.0.0.9", first: "10.0.0.1", last: "10.0.0.9", blocked: true},
+		{name: "dat", line: "001.002.004.000 - 001.002.004.255 , 000 , Some Organization", first: "1.2.4.0", last: "1.2.4.255", blocked: true},
+		{name: "dat allowed level", line: "001.002.004.000 - 001.002.004.255 , 200 , Some Organization", blocked: false},
+		{name: "dat level 127", line: "001.002.004.000 - 001.002.004.255 , 127 , Some Organization", first: "1.2.4.0", last: "1.2.4.255", blocked: true},
+		{name: "p2p", line: "Some Organization:17.0.0.0-17.255.255.255", first: "17.0.0.0", last: "17.255.255.255", blocked: true},
+		{name: "p2p description with comma", line: "Apple Computer, Inc:17.0.0.0-17.255.255.255", first: "17.0.0.0", last: "17.255.255.255", blocked: true},
+		{name: "ipv6 range", line: "2001:db8::1-2001:db8::ff", first: "2001:db8::1", last: "2001:db8::ff", blocked: true},
+		{name: "reversed range", line: "10.0.0.9-10.0.0.1", err: true},
+		{name: "mixed families", line: "10.0.0.1-2001:db8::1", err: true},
+		{name: "invalid octet", line: "1.2.3.256", err: true},
+		{name: "too many parts", line: "1.2.3.4-1.2.3.5-1.2.3.6", err: true},
+		{name: "not an ip", line: "some text", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, blocked, err := parseBlocklistLine(test.line)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if blocked != test.blocked {
+				t.Fatalf("expected blocked %t, got %t", test.blocked, blocked)
+			}
+			if !blocked {
+				return
+			}
+			if first := r.first.String(); first != test.first {
+				t.Errorf("expected first %s, got %s", test.first, first)
+			}
+			if last := r.last.String(); last != test.last {
+				t.Errorf("expected last %s, got %s", test.last, last)
+			}
+		})
+	}
+}
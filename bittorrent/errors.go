@@ -13,6 +13,15 @@ var (
 	ReaderClosedError      = errors.New("reader was closed")
 	ReaderCloseNotifyError = errors.New("reader close notify received")
 	InvalidWhenceError     = errors.New("invalid whence")
+	InvalidOffsetError     = errors.New("invalid offset")
+	InvalidLengthError     = errors.New("invalid length")
 	TimeoutError           = errors.New("timeout reached")
 	NoMetadataError        = errors.New("no metadata")
+	DataUnavailableError   = errors.New("data not available yet")
+	InvalidIpError         = errors.New("invalid ip address")
+	InvalidAddressError    = errors.New("invalid peer address")
+	InvalidWebSeedError    = errors.New("invalid web seed url")
+	InvalidPriorityError   = errors.New("invalid priority")
+	InvalidAutoSelectError = errors.New("invalid auto select mode")
+	LoadedTorrentError     = errors.New("torrent is loaded")
 )
@@ -1,10 +1,14 @@
 package bittorrent
 
 import (
+	"context"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/i96751414/libtorrent-go"
+	"github.com/i96751414/torrest/container"
+	"github.com/i96751414/torrest/models"
 )
 
 type File struct {
@@ -20,24 +24,23 @@ type File struct {
 	bufferSize   int64
 	priority     uint
 	isBuffering  bool
+	readerAt     *reader
+	closed       bool
+	bufferId     uint64
+	duration     time.Duration
+	durationHint time.Duration
+	parsed       bool
 }
 
-type FileInfo struct {
-	Id     int    `json:"id"`
-	Length int64  `json:"length"`
-	Path   string `json:"path"`
-	Name   string `json:"name"`
-}
+// Margin applied to the file bitrate when estimating if playback can run to the
+// end without stalling
+const safeToStartMargin = 1.2
 
-type FileStatus struct {
-	Total             int64    `json:"total"`
-	TotalDone         int64    `json:"total_done"`
-	Progress          float64  `json:"progress"`
-	Priority          uint     `json:"priority"`
-	BufferingTotal    int64    `json:"buffering_total"`
-	BufferingProgress float64  `json:"buffering_progress"`
-	State             LTStatus `json:"state"`
-}
+// Default sizes buffered at the start and at the end of a file
+const (
+	startBufferPercent = 0.005
+	endBufferSize      = 10 * 1024 * 1024 // 10MB
+)
 
 func NewFile(torrent *Torrent, storage libtorrent.FileStorage, index int) *File {
 	f := &File{
@@ -61,8 +64,8 @@ func NewFile(torrent *Torrent, storage libtorrent.FileStorage, index int) *File
 	return f
 }
 
-func (f *File) Info() *FileInfo {
-	return &FileInfo{
+func (f *File) Info() *models.FileInfo {
+	return &models.FileInfo{
 		Id:     f.index,
 		Length: f.length,
 		Path:   f.path,
@@ -70,24 +73,92 @@ func (f *File) Info() *FileInfo {
 	}
 }
 
-func (f *File) Status() *FileStatus {
+// Status returns the file status. Besides the buffering progress, it includes
+// the estimated time (in seconds) for buffering to complete, which is -1 when
+// unknown, and whether playback is expected to reach the end without stalling.
+// The latter compares the download rate with the file bitrate (in bytes per
+// second), which requires the media duration to be known.
+func (f *File) Status() *models.FileStatus {
+	duration := f.Duration()
+	downloadRate := int64(f.torrent.downloadRate())
+
 	f.mu.RLock()
 	defer f.mu.RUnlock()
-	return &FileStatus{
+	status := &models.FileStatus{
 		Total:             f.length,
 		TotalDone:         f.BytesCompleted(),
 		Progress:          f.GetProgress(),
 		Priority:          f.priority,
 		BufferingTotal:    f.bufferSize,
 		BufferingProgress: f.getBufferingProgress(),
+		BufferingEta:      -1,
 		State:             f.GetState(),
 	}
+
+	bufferMissing := int64(0)
+	if f.isBuffering {
+		bufferMissing = f.bufferBytesMissing()
+	}
+	if bufferMissing == 0 {
+		status.BufferingEta = 0
+	} else if downloadRate > 0 {
+		status.BufferingEta = (bufferMissing + downloadRate - 1) / downloadRate
+	}
+
+	if duration > 0 {
+		status.Bitrate = int64(float64(f.length) / duration.Seconds())
+	}
+	status.SafeToStart = bufferMissing == 0 && (status.TotalDone == status.Total ||
+		status.Bitrate > 0 && float64(downloadRate) >= float64(status.Bitrate)*safeToStartMargin)
+
+	return status
+}
+
+// Duration returns the media duration, either from the container or from
+// the hint, or 0 if unknown. The container is only parsed once the required
+// data is available.
+func (f *File) Duration() time.Duration {
+	f.mu.RLock()
+	parsed := f.parsed
+	f.mu.RUnlock()
+
+	if !parsed {
+		// Keep trying while the data is not available
+		info, err := container.Parse(&availableReaderAt{f}, f.length, f.name)
+		if err != DataUnavailableError {
+			f.mu.Lock()
+			f.parsed = true
+			if err == nil {
+				f.duration = info.Duration
+			}
+			f.mu.Unlock()
+		}
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.duration > 0 {
+		return f.duration
+	}
+	return f.durationHint
+}
+
+// SetDurationHint sets the media duration to use when it is not possible to
+// get it from the container
+func (f *File) SetDurationHint(duration time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.durationHint = duration
 }
 
 func (f *File) Id() int {
 	return f.index
 }
 
+func (f *File) Torrent() *Torrent {
+	return f.torrent
+}
+
 func (f *File) Length() int64 {
 	return f.length
 }
@@ -101,7 +172,49 @@ func (f *File) Name() string {
 }
 
 func (f *File) NewReader() *reader {
-	return newReader(f.torrent, f.offset, f.length, f.pieceLength, 0.01)
+	return f.NewReaderContext(context.Background())
+}
+
+// NewReaderContext creates a file reader whose reads stop waiting for pieces
+// once ctx is done.
+func (f *File) NewReaderContext(ctx context.Context) *reader {
+	return newReader(ctx, f.torrent, f.offset, f.length, f.pieceLength, 0.01)
+}
+
+// ReadAt reads len(b) bytes starting at byte offset off of the file, waiting
+// for the necessary pieces. It is safe for concurrent use.
+func (f *File) ReadAt(b []byte, off int64) (int, error) {
+	return f.ReadAtContext(context.Background(), b, off)
+}
+
+// ReadAtContext is like ReadAt, but stops waiting for pieces once ctx is done.
+func (f *File) ReadAtContext(ctx context.Context, b []byte, off int64) (int, error) {
+	return f.sharedReader().ReadAtContext(ctx, b, off)
+}
+
+func (f *File) sharedReader() *reader {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.readerAt == nil {
+		f.readerAt = f.NewReader()
+		if f.closed {
+			_ = f.readerAt.Close()
+		}
+	}
+	return f.readerAt
+}
+
+// close closes the reader shared by ReadAt, releasing the pieces it requested.
+// Must be called when the file is no longer used.
+func (f *File) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		f.closed = true
+		if f.readerAt != nil {
+			_ = f.readerAt.Close()
+		}
+	}
 }
 
 func (f *File) GetDownloadPath() string {
@@ -121,6 +234,21 @@ func (f *File) getPiecesIndexes(off, length int64) (firstPieceIndex, endPieceInd
 	return
 }
 
+// HasBytes checks if all the pieces of the given file range are already
+// downloaded, i.e. if reading them would not block
+func (f *File) HasBytes(off, length int64) bool {
+	if length <= 0 || off >= f.length {
+		return true
+	}
+	startPiece, endPiece := f.getPiecesIndexes(off, length)
+	for piece := startPiece; piece <= endPiece; piece++ {
+		if !f.torrent.handle.HavePiece(piece) {
+			return false
+		}
+	}
+	return true
+}
+
 func (f *File) GetProgress() float64 {
 	return 100 * float64(f.BytesCompleted()) / float64(f.length)
 }
@@ -135,6 +263,7 @@ func (f *File) SetPriority(priority uint) {
 	defer f.mu.Unlock()
 
 	f.priority = priority
+	f.bufferId++
 	f.isBuffering = false
 	f.bufferSize = 0
 	f.bufferPieces = nil
@@ -172,33 +301,108 @@ func (f *File) addBufferPiece(piece int, info libtorrent.TorrentInfo) {
 	f.bufferPieces = append(f.bufferPieces, piece)
 }
 
-func (f *File) Buffer(startBufferSize, endBufferSize int64) {
-	log.Debugf("Buffering file %s:%d", f.torrent.infoHash, f.index)
-	f.mu.Lock()
-	defer f.mu.Unlock()
-
+// setBufferRanges replaces the buffer pieces by the ones of the given ranges.
+// Must be called with the lock held.
+func (f *File) setBufferRanges(ranges ...container.Range) {
+	f.bufferId++
 	f.bufferSize = 0
 	f.bufferPieces = nil
 	info := f.torrent.handle.TorrentFile()
 
+	added := make(map[int]bool)
+	for _, r := range ranges {
+		if r.Length <= 0 {
+			continue
+		}
+		firstPieceIndex, endPieceIndex := f.getPiecesIndexes(r.Offset, r.Length)
+		for idx := firstPieceIndex; idx <= endPieceIndex; idx++ {
+			if !added[idx] {
+				added[idx] = true
+				f.addBufferPiece(idx, info)
+			}
+		}
+	}
+
+	f.isBuffering = true
+}
+
+func (f *File) headTailRanges(startBufferSize, endBufferSize int64) []container.Range {
 	if f.length >= startBufferSize+endBufferSize {
-		aFirstPieceIndex, aEndPieceIndex := f.getPiecesIndexes(0, startBufferSize)
-		for idx := aFirstPieceIndex; idx <= aEndPieceIndex; idx++ {
-			f.addBufferPiece(idx, info)
+		return []container.Range{
+			{Offset: 0, Length: startBufferSize},
+			{Offset: f.length - endBufferSize, Length: endBufferSize},
 		}
+	}
+	return []container.Range{{Offset: 0, Length: f.length}}
+}
+
+// BufferSizes returns the default sizes to buffer at the start and at the end
+// of the file. The start buffer is a percentage of the file length, but never
+// less than minStartBufferSize.
+func (f *File) BufferSizes(minStartBufferSize int64) (startBufferSize, endBufferSize int64) {
+	startBufferSize = int64(float64(f.length) * startBufferPercent)
+	if startBufferSize < minStartBufferSize {
+		startBufferSize = minStartBufferSize
+	}
+	return startBufferSize, endBufferSize
+}
+
+func (f *File) Buffer(startBufferSize, endBufferSize int64) {
+	log.Debugf("Buffering file %s:%d", f.torrent.infoHash, f.index)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.setBufferRanges(f.headTailRanges(startBufferSize, endBufferSize)...)
+}
 
-		bFirstPieceIndex, bEndPieceIndex := f.getPiecesIndexes(f.length-endBufferSize, endBufferSize)
-		for idx := bFirstPieceIndex; idx <= bEndPieceIndex; idx++ {
-			f.addBufferPiece(idx, info)
+// BufferContainer buffers the metadata and index regions of MP4 and MKV files,
+// found by parsing the container structure, plus startBufferSize bytes of media
+// data. Parsing is done in the background and, if it fails, the file is
+// buffered as with Buffer.
+func (f *File) BufferContainer(startBufferSize, endBufferSize int64) {
+	log.Debugf("Buffering file %s:%d container", f.torrent.infoHash, f.index)
+	f.mu.Lock()
+	// The start of the file is always needed for parsing the container
+	f.setBufferRanges(container.Range{Offset: 0, Length: startBufferSize})
+	id := f.bufferId
+	f.mu.Unlock()
+
+	go func() {
+		var ranges []container.Range
+		if info, err := container.Parse(f, f.length, f.name); err == nil {
+			ranges = append(info.Ranges, container.Range{Offset: info.MediaOffset, Length: startBufferSize})
+			f.mu.Lock()
+			f.parsed = true
+			f.duration = info.Duration
+			f.mu.Unlock()
+		} else {
+			log.Infof("Unable to parse container of file %s:%d, using regular buffering: %s", f.torrent.infoHash, f.index, err)
+			ranges = f.headTailRanges(startBufferSize, endBufferSize)
 		}
-	} else {
-		firstPieceIndex, endPieceIndex := f.getPiecesIndexes(0, f.length)
-		for idx := firstPieceIndex; idx <= endPieceIndex; idx++ {
-			f.addBufferPiece(idx, info)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		// Buffering may have been changed or stopped in the meantime
+		if f.bufferId == id {
+			f.setBufferRanges(ranges...)
 		}
+	}()
+}
+
+// BufferRange buffers the [off, off+length) range of the file, which is useful
+// before seeking. The buffering progress is reported as with Buffer.
+func (f *File) BufferRange(off, length int64) error {
+	if off < 0 || off >= f.length {
+		return InvalidOffsetError
+	}
+	if length <= 0 {
+		return InvalidLengthError
 	}
 
-	f.isBuffering = true
+	log.Debugf("Buffering file %s:%d range [%d, %d)", f.torrent.infoHash, f.index, off, off+length)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.setBufferRanges(container.Range{Offset: off, Length: length})
+	return nil
 }
 
 func (f *File) bufferBytesMissing() int64 {
@@ -216,7 +420,7 @@ func (f *File) getBufferingProgress() float64 {
 	return float64(f.bufferBytesCompleted()) / float64(f.bufferSize) * 100.0
 }
 
-func (f *File) GetState() LTStatus {
+func (f *File) GetState() models.LTStatus {
 	return f.torrent.getState(f)
 }
 
@@ -241,3 +445,21 @@ func (f *File) BufferBytesCompleted() int64 {
 	defer f.mu.RUnlock()
 	return f.bufferBytesCompleted()
 }
+
+// availableReaderAt reads the file without ever waiting for pieces, failing
+// with DataUnavailableError if the data is not downloaded yet
+type availableReaderAt struct {
+	file *File
+}
+
+func (r *availableReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, InvalidOffsetError
+	}
+	reader := r.file.sharedReader()
+	n, err := reader.readAt(context.Background(), b, off, false)
+	if err == nil && n < len(b) {
+		err = reader.shortReadError(off, n)
+	}
+	return n, err
+}
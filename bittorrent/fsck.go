@@ -0,0 +1,223 @@
+package bittorrent
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/i96751414/torrest/settings"
+	"github.com/zeebo/bencode"
+)
+
+type FsckIssueType string
+
+const (
+	OrphanedResumeIssue   FsckIssueType = "orphaned_resume"
+	CorruptResumeIssue    FsckIssueType = "corrupt_resume"
+	CorruptTorrentIssue   FsckIssueType = "corrupt_torrent"
+	DuplicateMagnetIssue  FsckIssueType = "duplicate_magnet"
+	StalePartsIssue       FsckIssueType = "stale_parts"
+	UnreferencedDataIssue FsckIssueType = "unreferenced_data"
+)
+
+type FsckIssue struct {
+	Type  FsckIssueType `json:"type" example:"stale_parts"`
+	Path  string        `json:"path" example:"downloads/.000102030405060708090a0b0c0d0e0f10111213.parts"`
+	Fixed bool          `json:"fixed" example:"false"`
+	Error string        `json:"error,omitempty" example:""`
+}
+
+type FsckReport struct {
+	Issues []FsckIssue `json:"issues"`
+}
+
+// Fsck checks the consistency of the service files while holding the
+// service lock, so no torrents are added or removed in the meantime. The
+// data of the loaded torrents is identified by their files paths. The files
+// of the loaded torrents are written while the service runs, so their issues
+// are only reported, even if fix is set.
+func (s *Service) Fsck(fix bool) *FsckReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	referenced := make(map[string]bool)
+	loaded := make(map[string]bool, len(s.torrents))
+	for _, t := range s.torrents {
+		loaded[t.infoHash] = true
+		t.mu.RLock()
+		for _, f := range t.files {
+			referenced[strings.SplitN(filepath.ToSlash(f.Path()), "/", 2)[0]] = true
+		}
+		t.mu.RUnlock()
+	}
+	return fsck(s.config, fix, referenced, loaded)
+}
+
+// Fsck scans the torrents and download paths looking for corrupt torrent
+// files, orphaned or corrupt resume files, duplicate magnets, stale parts
+// files and downloaded data not referenced by any torrent. If fix is set, the
+// problematic files are removed, except for the unreferenced data, which is
+// only reported, as torrent names may not match the data on disk. It does not
+// require a running service.
+func Fsck(config *settings.Settings, fix bool) *FsckReport {
+	return fsck(config, fix, make(map[string]bool), make(map[string]bool))
+}
+
+// IsFixable returns whether the issue is removed by fsck when fixing. The
+// unreferenced data is only reported.
+func (i *FsckIssue) IsFixable() bool {
+	return i.Type != UnreferencedDataIssue
+}
+
+// fsck runs the check, considering the provided download path entries as
+// referenced, in addition to the ones named after the torrents files. The
+// files of the live torrents are never removed.
+func fsck(config *settings.Settings, fix bool, referenced, live map[string]bool) *FsckReport {
+	report := &FsckReport{Issues: []FsckIssue{}}
+	addIssue := func(issueType FsckIssueType, infoHash, path string) {
+		issue := FsckIssue{Type: issueType, Path: path}
+		if fix && issue.IsFixable() {
+			if live[infoHash] {
+				issue.Error = LoadedTorrentError.Error()
+			} else if err := os.RemoveAll(path); err == nil {
+				issue.Fixed = true
+			} else {
+				issue.Error = err.Error()
+			}
+		}
+		log.Warningf("fsck: %s '%s' (fixed=%t)", issueType, path, issue.Fixed)
+		report.Issues = append(report.Issues, issue)
+	}
+
+	torrents := globInfoHashes(config.TorrentsPath, "", extTorrent)
+	magnets := globInfoHashes(config.TorrentsPath, "", extMagnet)
+	resumes := globInfoHashes(config.TorrentsPath, "", extFastResume)
+
+	// Info hashes of the torrents which are loaded by the service on startup
+	loaded := make(map[string]bool)
+
+	for infoHash, path := range resumes {
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			_, err = DecodeTorrentData(data)
+		}
+		_, hasTorrent := torrents[infoHash]
+		_, hasMagnet := magnets[infoHash]
+		if err != nil {
+			addIssue(CorruptResumeIssue, infoHash, path)
+			continue
+		}
+		// Never consider data referenced by any file as unreferenced, even
+		// if the file itself is removed
+		if name := decodeResumeName(data); name != "" {
+			referenced[name] = true
+		}
+		if !hasTorrent && !hasMagnet {
+			addIssue(OrphanedResumeIssue, infoHash, path)
+			if fix {
+				continue
+			}
+		}
+		loaded[infoHash] = true
+	}
+
+	for infoHash, path := range torrents {
+		loaded[infoHash] = true
+		data, err := ioutil.ReadFile(path)
+		var torrentFile *TorrentFileRaw
+		if err == nil {
+			torrentFile, err = DecodeTorrentData(data)
+		}
+		if err != nil {
+			addIssue(CorruptTorrentIssue, infoHash, path)
+		} else if name, ok := torrentFile.Info["name"].(string); ok {
+			referenced[name] = true
+		}
+		if magnetPath, ok := magnets[infoHash]; ok {
+			addIssue(DuplicateMagnetIssue, infoHash, magnetPath)
+			delete(magnets, infoHash)
+		}
+	}
+
+	for infoHash, path := range magnets {
+		loaded[infoHash] = true
+		if name := magnetDisplayName(path); name != "" {
+			referenced[name] = true
+		}
+	}
+
+	for infoHash, path := range globInfoHashes(config.DownloadPath, ".", extParts) {
+		if !loaded[infoHash] {
+			addIssue(StalePartsIssue, infoHash, path)
+		}
+	}
+
+	if entries, err := ioutil.ReadDir(config.DownloadPath); err == nil {
+		torrentsPath, _ := filepath.Abs(config.TorrentsPath)
+		for _, entry := range entries {
+			name := entry.Name()
+			path := filepath.Join(config.DownloadPath, name)
+			if referenced[name] || strings.HasSuffix(name, extParts) {
+				continue
+			}
+			if absPath, _ := filepath.Abs(path); absPath == torrentsPath ||
+				strings.HasPrefix(torrentsPath, absPath+string(filepath.Separator)) {
+				continue
+			}
+			addIssue(UnreferencedDataIssue, "", path)
+		}
+	} else if !os.IsNotExist(err) {
+		log.Errorf("fsck: failed listing '%s': %s", config.DownloadPath, err)
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Path < report.Issues[j].Path
+	})
+	return report
+}
+
+// globInfoHashes maps the info hashes of the files with the provided prefix
+// and extension to their paths
+func globInfoHashes(dir, prefix, ext string) map[string]string {
+	files := make(map[string]string)
+	paths, _ := filepath.Glob(filepath.Join(dir, prefix+"*"+ext))
+	for _, path := range paths {
+		infoHash := strings.TrimPrefix(strings.TrimSuffix(filepath.Base(path), ext), prefix)
+		files[infoHash] = path
+	}
+	return files
+}
+
+// magnetDisplayName returns the display name (dn) of a saved magnet, which
+// usually matches the torrent name
+func magnetDisplayName(path string) string {
+	data := Magnet{}
+	if err := readGobData(path, &data); err != nil {
+		return ""
+	}
+	if u, err := url.Parse(data.Uri); err == nil {
+		return u.Query().Get("dn")
+	}
+	return ""
+}
+
+// decodeResumeName returns the torrent name stored in the resume data
+func decodeResumeName(data []byte) string {
+	var resume struct {
+		Name string `bencode:"name"`
+		Info struct {
+			Name string `bencode:"name"`
+		} `bencode:"info"`
+	}
+	if err := bencode.NewDecoder(bytes.NewReader(data)).Decode(&resume); err != nil {
+		return ""
+	}
+	if resume.Info.Name != "" {
+		return resume.Info.Name
+	}
+	return resume.Name
+}
@@ -0,0 +1,74 @@
+package bittorrent
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+
+	"github.com/i96751414/libtorrent-go"
+	"github.com/i96751414/torrest/models"
+)
+
+// GetMetadata returns the torrent metadata. If the metadata was not received
+// yet, only the info hash, name, trackers and web seeds are available.
+func (t *Torrent) GetMetadata() *models.TorrentMetadata {
+	metadata := &models.TorrentMetadata{
+		InfoHash: t.infoHash,
+		Trackers: t.trackers(),
+		WebSeeds: t.WebSeeds(),
+	}
+	if info := t.handle.TorrentFile(); info.Swigcptr() != 0 {
+		metadata.Name = info.Name()
+		metadata.Size = info.TotalSize()
+		metadata.HasMetadata = true
+		metadata.Comment = info.Comment()
+		metadata.Creator = info.Creator()
+		metadata.CreationDate = int64(info.CreationDate())
+		metadata.PieceLength = info.PieceLength()
+		metadata.NumPieces = info.NumPieces()
+		metadata.Private = info.Priv()
+		metadata.NumFiles = info.NumFiles()
+	} else {
+		metadata.Name = t.defaultName
+	}
+	return metadata
+}
+
+// TorrentFile returns the contents of the stored .torrent file, which is only
+// available after receiving the torrent metadata
+func (t *Torrent) TorrentFile() ([]byte, error) {
+	data, err := ioutil.ReadFile(t.service.torrentPath(t.infoHash))
+	if os.IsNotExist(err) {
+		err = NoMetadataError
+	}
+	return data, err
+}
+
+// MagnetUri generates the torrent magnet uri, optionally including its
+// trackers. Web seeds are always included.
+func (t *Torrent) MagnetUri(includeTrackers bool) string {
+	magnet := "magnet:?xt=urn:btih:" + t.infoHash
+	if name := t.GetInfo().Name; name != t.infoHash {
+		magnet += "&dn=" + url.QueryEscape(name)
+	}
+	if includeTrackers {
+		for _, tracker := range t.trackers() {
+			magnet += "&tr=" + url.QueryEscape(tracker)
+		}
+	}
+	for _, webSeed := range t.WebSeeds() {
+		magnet += "&ws=" + url.QueryEscape(webSeed)
+	}
+	return magnet
+}
+
+func (t *Torrent) trackers() []string {
+	entries := t.handle.Trackers()
+	defer libtorrent.DeleteStdVectorAnnounceEntry(entries)
+
+	trackers := make([]string, 0, entries.Size())
+	for i := 0; i < int(entries.Size()); i++ {
+		trackers = append(trackers, entries.Get(i).GetUrl())
+	}
+	return trackers
+}
@@ -0,0 +1,115 @@
+package bittorrent
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/i96751414/libtorrent-go"
+	"github.com/i96751414/torrest/settings"
+)
+
+// ValidateOverrides checks that all the overrides are known libtorrent settings
+// and that their values have the correct type
+func ValidateOverrides(overrides settings.Overrides) error {
+	for name, value := range overrides {
+		if _, err := overrideValue(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overrideValue returns the value converted to the type of the libtorrent
+// setting with the given name
+func overrideValue(name string, value interface{}) (interface{}, error) {
+	index := libtorrent.SettingByName(name)
+	if index < 0 {
+		return nil, fmt.Errorf("unknown libtorrent setting '%s'", name)
+	}
+
+	switch index & libtorrent.SettingsPackTypeMask {
+	case libtorrent.SettingsPackStringTypeBase:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("libtorrent setting '%s' must be a string", name)
+	case libtorrent.SettingsPackIntTypeBase:
+		if v, ok := value.(float64); ok && v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+			return int(v), nil
+		}
+		return nil, fmt.Errorf("libtorrent setting '%s' must be an integer", name)
+	case libtorrent.SettingsPackBoolTypeBase:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("libtorrent setting '%s' must be a boolean", name)
+	}
+	return nil, fmt.Errorf("invalid libtorrent setting '%s'", name)
+}
+
+// resetOverrides restores the libtorrent default value of previously
+// overridden settings, so that removed overrides stop having effect. Must be
+// called before all the other settings.
+func (s *Service) resetOverrides() {
+	if len(s.overridden) == 0 {
+		return
+	}
+	defaults := libtorrent.DefaultSettings()
+	defer libtorrent.DeleteSettingsPack(defaults)
+
+	for _, name := range s.overridden {
+		switch libtorrent.SettingByName(name) & libtorrent.SettingsPackTypeMask {
+		case libtorrent.SettingsPackStringTypeBase:
+			s.settingsPack.SetStr(name, defaults.GetStr(name))
+		case libtorrent.SettingsPackIntTypeBase:
+			s.settingsPack.SetInt(name, defaults.GetInt(name))
+		case libtorrent.SettingsPackBoolTypeBase:
+			s.settingsPack.SetBool(name, defaults.GetBool(name))
+		}
+	}
+	s.overridden = nil
+}
+
+// applyOverrides sets the overrides in the settings pack, skipping the invalid
+// ones. Must be called after all the other settings.
+func (s *Service) applyOverrides() {
+	for name, value := range s.config.LibtorrentOverrides {
+		v, err := overrideValue(name, value)
+		if err != nil {
+			log.Warningf("Ignoring override: %s", err)
+			continue
+		}
+		log.Debugf("Overriding libtorrent setting %s=%v", name, v)
+		s.overridden = append(s.overridden, name)
+		switch v := v.(type) {
+		case string:
+			s.settingsPack.SetStr(name, v)
+		case int:
+			s.settingsPack.SetInt(name, v)
+		case bool:
+			s.settingsPack.SetBool(name, v)
+		}
+	}
+}
+
+// LibtorrentSettings returns the current value of all libtorrent settings
+func (s *Service) LibtorrentSettings() map[string]interface{} {
+	pack := s.session.GetSettings()
+	defer libtorrent.DeleteSettingsPack(pack)
+
+	values := make(map[string]interface{})
+	addSettings := func(base, end int, get func(string) interface{}) {
+		for index := base; index < end; index++ {
+			if name := libtorrent.NameForSetting(index); name != "" {
+				values[name] = get(name)
+			}
+		}
+	}
+	addSettings(libtorrent.SettingsPackStringTypeBase, int(libtorrent.SettingsPackMaxStringSettingInternal),
+		func(name string) interface{} { return pack.GetStr(name) })
+	addSettings(libtorrent.SettingsPackIntTypeBase, int(libtorrent.SettingsPackMaxIntSettingInternal),
+		func(name string) interface{} { return pack.GetInt(name) })
+	addSettings(libtorrent.SettingsPackBoolTypeBase, int(libtorrent.SettingsPackMaxBoolSettingInternal),
+		func(name string) interface{} { return pack.GetBool(name) })
+	return values
+}
@@ -0,0 +1,40 @@
+package bittorrent
+
+import "time"
+
+type PeerType string
+
+const WebSeedPeer PeerType = "web_seed"
+
+// PeerInfo is an entry of the torrent peer list. Failed is only set while the
+// last error is recent, but the last error is always reported.
+type PeerInfo struct {
+	Type      PeerType `json:"type" example:"web_seed"`
+	Address   string   `json:"address" example:"https://mirror.example.com/files/"`
+	Failed    bool     `json:"failed" example:"false"`
+	Error     string   `json:"error,omitempty" example:""`
+	ErrorTime int64    `json:"error_time,omitempty" example:"0"`
+}
+
+// Peers returns the torrent peer list. Libtorrent-go does not expose the swarm
+// peers (peer_info), so only the web seeds are listed. Libtorrent only reports
+// web seeds failures, so a web seed is considered to be working until an error
+// is received, and again once the error expires.
+func (t *Torrent) Peers() []*PeerInfo {
+	w := t.service.webSeeds
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	webSeeds := t.WebSeeds()
+	peers := make([]*PeerInfo, len(webSeeds))
+	for i, webSeed := range webSeeds {
+		peer := &PeerInfo{Type: WebSeedPeer, Address: webSeed}
+		if e, ok := w.errors[t.infoHash][webSeed]; ok {
+			peer.Failed = time.Since(e.time) < webSeedErrorExpiration
+			peer.Error = e.message
+			peer.ErrorTime = e.time.Unix()
+		}
+		peers[i] = peer
+	}
+	return peers
+}
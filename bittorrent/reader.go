@@ -1,6 +1,7 @@
 package bittorrent
 
 import (
+	"context"
 	"errors"
 	"io"
 	"sync"
@@ -15,6 +16,7 @@ const (
 
 type reader struct {
 	mu               *sync.Mutex
+	ctx              context.Context
 	storage          libtorrent.StorageInterface
 	torrent          *Torrent
 	offset           int64
@@ -22,16 +24,28 @@ type reader struct {
 	pieceLength      int64
 	priorityPieces   int
 	closing          chan interface{}
+	closeOnce        *sync.Once
 	firstPiece       int
 	lastPiece        int
 	closeNotifiers   []<-chan bool
 	pos              int64
 	pieceWaitTimeout time.Duration
+	requestsMu       *sync.Mutex
+	requests         map[int]struct{}
 }
 
-func newReader(torrent *Torrent, offset, length, pieceLength int64, readAhead float64) *reader {
+// pieceRequest holds the number of readers requesting a piece, the highest
+// priority they set and the priority the piece had before the first request
+type pieceRequest struct {
+	readers  int
+	previous uint
+	priority uint
+}
+
+func newReader(ctx context.Context, torrent *Torrent, offset, length, pieceLength int64, readAhead float64) *reader {
 	r := &reader{
 		mu:               &sync.Mutex{},
+		ctx:              ctx,
 		storage:          torrent.handle.GetStorageImpl(),
 		torrent:          torrent,
 		offset:           offset,
@@ -39,7 +53,10 @@ func newReader(torrent *Torrent, offset, length, pieceLength int64, readAhead fl
 		pieceLength:      pieceLength,
 		priorityPieces:   int(0.5 + readAhead*float64(length)/float64(pieceLength)),
 		closing:          make(chan interface{}),
+		closeOnce:        &sync.Once{},
 		pieceWaitTimeout: torrent.service.config.PieceWaitTimeout * time.Second,
+		requestsMu:       &sync.Mutex{},
+		requests:         make(map[int]struct{}),
 	}
 	r.firstPiece = r.pieceFromOffset(0)
 	r.lastPiece = r.pieceFromOffset(length - 1)
@@ -50,7 +67,7 @@ func (r *reader) RegisterCloseNotifier(n <-chan bool) {
 	r.closeNotifiers = append(r.closeNotifiers, n)
 }
 
-func (r *reader) waitForPiece(piece int, timeout time.Duration) error {
+func (r *reader) waitForPiece(ctx context.Context, piece int, timeout time.Duration) error {
 	log.Debugf("Waiting for piece %d on '%s'", piece, r.torrent.infoHash)
 
 	startTime := time.Now()
@@ -65,6 +82,8 @@ func (r *reader) waitForPiece(piece int, timeout time.Duration) error {
 			return TorrentClosedError
 		case <-r.closing:
 			return ReaderClosedError
+		case <-ctx.Done():
+			return ctx.Err()
 		case <-pieceRefreshTicker.C:
 			if timeout != 0 && time.Since(startTime) >= timeout {
 				log.Warningf("Timed out waiting for piece %d with priority %v for '%s'",
@@ -107,12 +126,62 @@ func (r *reader) Read(b []byte) (int, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	startPiece := r.pieceFromOffset(r.pos)
-	endPiece := r.pieceFromOffset(r.pos + int64(len(b)) - 1)
-	r.setPiecesPriorities(startPiece, endPiece-startPiece)
+	n, err := r.readAt(r.ctx, b, r.pos, true)
+	r.pos += int64(n)
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt. Unlike Read, it does not use nor modify the
+// reader position, so it is safe to call it concurrently.
+func (r *reader) ReadAt(b []byte, off int64) (int, error) {
+	return r.ReadAtContext(context.Background(), b, off)
+}
+
+// ReadAtContext is like ReadAt, but stops waiting for pieces once ctx is done.
+func (r *reader) ReadAtContext(ctx context.Context, b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, InvalidOffsetError
+	}
+	n, err := r.readAt(ctx, b, off, true)
+	if err == nil && n < len(b) {
+		err = r.shortReadError(off, n)
+	}
+	return n, err
+}
+
+// shortReadError returns the error of a read of n bytes at off which did not
+// fill the buffer, which is only io.EOF if the end of the file was reached
+func (r *reader) shortReadError(off int64, n int) error {
+	if off+int64(n) == r.length {
+		return io.EOF
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// readAt reads from the storage. If wait is set, the pieces are prioritized and
+// waited for, otherwise DataUnavailableError is returned when missing.
+func (r *reader) readAt(ctx context.Context, b []byte, off int64, wait bool) (int, error) {
+	if off >= r.length {
+		return 0, io.EOF
+	}
+	if remaining := r.length - off; int64(len(b)) > remaining {
+		b = b[:remaining]
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	startPiece := r.pieceFromOffset(off)
+	endPiece := r.pieceFromOffset(off + int64(len(b)) - 1)
+	if wait {
+		r.setPiecesPriorities(startPiece, endPiece-startPiece)
+	}
 	for p := startPiece; p <= endPiece; p++ {
 		if !r.torrent.handle.HavePiece(p) {
-			if err := r.waitForPiece(p, r.pieceWaitTimeout); err != nil {
+			if !wait {
+				return 0, DataUnavailableError
+			}
+			if err := r.waitForPiece(ctx, p, r.pieceWaitTimeout); err != nil {
 				return 0, err
 			}
 		}
@@ -120,27 +189,93 @@ func (r *reader) Read(b []byte) (int, error) {
 
 	storageError := libtorrent.NewStorageError()
 	defer libtorrent.DeleteStorageError(storageError)
-	n := r.storage.Read(b, int64(len(b)), startPiece, int(r.pieceOffsetFromOffset(r.pos)), storageError)
+	n := r.storage.Read(b, int64(len(b)), startPiece, int(r.pieceOffsetFromOffset(off)), storageError)
 	if ec := storageError.GetEc(); ec.Failed() {
 		message := ec.Message().(string)
 		log.Errorf("Storage read error: %s", message)
 		return n, errors.New(message)
 	}
 
-	r.pos += int64(n)
 	return n, nil
 }
 
+// Close stops the pending reads and releases the pieces requested by the
+// reader. It is safe to call it more than once.
 func (r *reader) Close() error {
-	log.Debugf("Closing reader for '%s'", r.torrent.infoHash)
-	close(r.closing)
+	r.closeOnce.Do(func() {
+		log.Debugf("Closing reader for '%s'", r.torrent.infoHash)
+		close(r.closing)
+
+		r.requestsMu.Lock()
+		defer r.requestsMu.Unlock()
+		for piece := range r.requests {
+			r.torrent.releasePiece(piece)
+		}
+		r.requests = nil
+	})
 	return nil
 }
 
 func (r *reader) setPiecePriority(piece int, deadline int, priority uint) {
-	if r.torrent.handle.PiecePriority(piece).(uint) < priority {
-		r.torrent.handle.PiecePriority(piece, priority)
-		r.torrent.handle.SetPieceDeadline(piece, deadline)
+	r.requestsMu.Lock()
+	defer r.requestsMu.Unlock()
+	if r.requests == nil {
+		// The reader is closed
+		return
+	}
+	_, requested := r.requests[piece]
+	r.torrent.requestPiece(piece, deadline, priority, !requested)
+	r.requests[piece] = struct{}{}
+}
+
+// requestPiece raises the priority of the piece, if lower than the provided
+// one. A new request is counted so the piece is only released once all of its
+// readers are closed.
+func (t *Torrent) requestPiece(piece int, deadline int, priority uint, newRequest bool) {
+	t.piecesMu.Lock()
+	defer t.piecesMu.Unlock()
+
+	current := t.handle.PiecePriority(piece).(uint)
+	request, ok := t.pieceRequests[piece]
+	if !ok {
+		request = &pieceRequest{previous: current, priority: current}
+		t.pieceRequests[piece] = request
+	}
+	if newRequest {
+		request.readers++
+	}
+	if current < priority {
+		t.handle.PiecePriority(piece, priority)
+		t.handle.SetPieceDeadline(piece, deadline)
+		request.priority = priority
+	}
+}
+
+// releasePiece releases a reader request of the piece. Once the piece has no
+// more readers, its previous priority is restored if it was not downloaded yet
+// and its priority was not changed meanwhile.
+func (t *Torrent) releasePiece(piece int) {
+	t.piecesMu.Lock()
+	defer t.piecesMu.Unlock()
+
+	request, ok := t.pieceRequests[piece]
+	if !ok {
+		return
+	}
+	if request.readers--; request.readers > 0 {
+		return
+	}
+	delete(t.pieceRequests, piece)
+
+	select {
+	case <-t.closing:
+		// The torrent handle is no longer valid
+	default:
+		if !t.handle.HavePiece(piece) && t.handle.PiecePriority(piece).(uint) == request.priority &&
+			request.priority != request.previous {
+			t.handle.ResetPieceDeadline(piece)
+			t.handle.PiecePriority(piece, request.previous)
+		}
 	}
 }
 
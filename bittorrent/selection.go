@@ -0,0 +1,120 @@
+package bittorrent
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var priorityNames = map[string]uint{
+	"dont_download": DontDownloadPriority,
+	"low":           LowPriority,
+	"default":       DefaultPriority,
+	"high":          HighPriority,
+	"top":           TopPriority,
+}
+
+// ParsePriority parses a priority either from its name (dont_download, low,
+// default, high or top) or its numeric value, between 0 and 7
+func ParsePriority(value string) (uint, error) {
+	if priority, ok := priorityNames[strings.ToLower(value)]; ok {
+		return priority, nil
+	}
+	if priority, err := strconv.ParseUint(value, 10, 8); err == nil && uint(priority) <= TopPriority {
+		return uint(priority), nil
+	}
+	return 0, InvalidPriorityError
+}
+
+// FileSelector matches torrent files by name, extension and size. Empty
+// criteria match all files.
+type FileSelector struct {
+	glob       string
+	regex      *regexp.Regexp
+	extensions []string
+	minSize    int64
+	maxSize    int64
+}
+
+// NewFileSelector creates a file selector. The glob pattern is matched against
+// the file name, or against the file path if it contains a path separator,
+// while the regex is matched against the file path. The extensions are
+// matched case-insensitively and sizes are in bytes, being ignored if 0.
+func NewFileSelector(glob, regex string, extensions []string, minSize, maxSize int64) (*FileSelector, error) {
+	selector := &FileSelector{glob: glob, minSize: minSize, maxSize: maxSize}
+	if glob != "" {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return nil, err
+		}
+	}
+	if regex != "" {
+		var err error
+		if selector.regex, err = regexp.Compile(regex); err != nil {
+			return nil, err
+		}
+	}
+	for _, ext := range extensions {
+		if ext = strings.TrimPrefix(strings.TrimSpace(ext), "."); ext != "" {
+			selector.extensions = append(selector.extensions, "."+strings.ToLower(ext))
+		}
+	}
+	return selector, nil
+}
+
+func (s *FileSelector) Matches(f *File) bool {
+	if s.glob != "" {
+		name := f.Name()
+		if strings.ContainsAny(s.glob, `/\`) {
+			name = f.Path()
+		}
+		if matched, _ := filepath.Match(s.glob, name); !matched {
+			return false
+		}
+	}
+	if s.regex != nil && !s.regex.MatchString(f.Path()) {
+		return false
+	}
+	if len(s.extensions) > 0 {
+		ext := strings.ToLower(filepath.Ext(f.Name()))
+		matched := false
+		for _, e := range s.extensions {
+			if e == ext {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if s.minSize > 0 && f.Length() < s.minSize {
+		return false
+	}
+	if s.maxSize > 0 && f.Length() > s.maxSize {
+		return false
+	}
+	return true
+}
+
+// SelectFiles sets the priority of the files matching the selector,
+// returning their ids. If exclusive is set, the other files are not
+// downloaded.
+func (t *Torrent) SelectFiles(selector *FileSelector, priority uint, exclusive bool) ([]int, error) {
+	files, err := t.Files()
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Selecting torrent %s files with priority %d and exclusive=%t", t.infoHash, priority, exclusive)
+
+	selected := []int{}
+	for _, f := range files {
+		if selector.Matches(f) {
+			f.SetPriority(priority)
+			selected = append(selected, f.Id())
+		} else if exclusive {
+			f.SetPriority(DontDownloadPriority)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,97 @@
+package bittorrent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		value    string
+		priority uint
+		err      error
+	}{
+		{value: "dont_download", priority: DontDownloadPriority},
+		{value: "low", priority: LowPriority},
+		{value: "Default", priority: DefaultPriority},
+		{value: "HIGH", priority: HighPriority},
+		{value: "top", priority: TopPriority},
+		{value: "0", priority: 0},
+		{value: "5", priority: 5},
+		{value: "7", priority: 7},
+		{value: "8", err: InvalidPriorityError},
+		{value: "-1", err: InvalidPriorityError},
+		{value: "", err: InvalidPriorityError},
+		{value: "normal", err: InvalidPriorityError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			priority, err := ParsePriority(test.value)
+			if err != test.err {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if priority != test.priority {
+				t.Fatalf("expected priority %d, got %d", test.priority, priority)
+			}
+		})
+	}
+}
+
+func TestFileSelector(t *testing.T) {
+	files := []*File{
+		{path: "Show/Season 1/Episode 1.mkv", name: "Episode 1.mkv", length: 700},
+		{path: "Show/Season 1/Episode 2.MP4", name: "Episode 2.MP4", length: 900},
+		{path: "Show/Season 1/Episode 1.srt", name: "Episode 1.srt", length: 50},
+		{path: "Show/Extras/Sample.mkv", name: "Sample.mkv", length: 20},
+	}
+
+	tests := []struct {
+		name       string
+		glob       string
+		regex      string
+		extensions []string
+		minSize    int64
+		maxSize    int64
+		matches    []int
+	}{
+		{name: "no criteria", matches: []int{0, 1, 2, 3}},
+		{name: "glob on name", glob: "Episode 1.*", matches: []int{0, 2}},
+		{name: "glob on path", glob: "Show/Extras/*", matches: []int{3}},
+		{name: "regex on path", regex: `Season \d+/.*\.(mkv|srt)$`, matches: []int{0, 2}},
+		{name: "extensions", extensions: []string{"mkv", ".mp4"}, matches: []int{0, 1, 3}},
+		{name: "extensions case insensitive", extensions: []string{" MKV "}, matches: []int{0, 3}},
+		{name: "min size", minSize: 100, matches: []int{0, 1}},
+		{name: "max size", maxSize: 50, matches: []int{2, 3}},
+		{name: "size range", minSize: 50, maxSize: 700, matches: []int{0, 2}},
+		{name: "combined", glob: "*.mkv", minSize: 100, matches: []int{0}},
+		{name: "no matches", extensions: []string{"avi"}, matches: []int{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector, err := NewFileSelector(test.glob, test.regex, test.extensions, test.minSize, test.maxSize)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			matches := []int{}
+			for i, f := range files {
+				if selector.Matches(f) {
+					matches = append(matches, i)
+				}
+			}
+			if !reflect.DeepEqual(matches, test.matches) {
+				t.Fatalf("expected matches %v, got %v", test.matches, matches)
+			}
+		})
+	}
+}
+
+func TestNewFileSelectorInvalid(t *testing.T) {
+	if _, err := NewFileSelector("[", "", nil, 0, 0); err == nil {
+		t.Error("expected error for invalid glob")
+	}
+	if _, err := NewFileSelector("", "(", nil, 0, 0); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
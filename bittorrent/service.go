@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/i96751414/libtorrent-go"
+	"github.com/i96751414/torrest/models"
 	"github.com/i96751414/torrest/settings"
 	"github.com/i96751414/torrest/util"
 	"github.com/op/go-logging"
@@ -36,6 +38,7 @@ var DefaultDhtBootstrapNodes = []string{
 const (
 	libtorrentAlertWaitTime = time.Second
 	libtorrentProgressTime  = time.Second
+	resumeDataSaveTimeout   = 30 * time.Second
 	maxFilesPerTorrent      = 1000
 )
 
@@ -60,23 +63,28 @@ type Service struct {
 	session      libtorrent.Session
 	config       *settings.Settings
 	settingsPack libtorrent.SettingsPack
+	overridden   []string
 	torrents     []*Torrent
 	mu           *sync.RWMutex
 	wg           *sync.WaitGroup
 	rateLimited  bool
 	closing      chan interface{}
+	blocklist    *blocklist
+	webSeeds     *webSeeds
+	autoSelect   *autoSelections
+	resolving    map[string]bool
 	UserAgent    string
 	downloadRate int64
 	uploadRate   int64
+	downloaded   int64
+	uploaded     int64
 	progress     float64
-}
-
-type ServiceStatus struct {
-	Progress     float64 `json:"progress"`
-	DownloadRate int64   `json:"download_rate"`
-	UploadRate   int64   `json:"upload_rate"`
-	NumTorrents  int     `json:"num_torrents"`
-	IsPaused     bool    `json:"is_paused"`
+	// Payload bytes transferred by the previous sessions
+	previousDownloaded int64
+	previousUploaded   int64
+	// Last run of the progress loop, used as liveness check
+	progressMu   *sync.Mutex
+	lastProgress time.Time
 }
 
 type Magnet struct {
@@ -95,15 +103,24 @@ func NewService(config *settings.Settings) *Service {
 		wg:           &sync.WaitGroup{},
 		rateLimited:  true,
 		closing:      make(chan interface{}),
+		blocklist:    newBlocklist(),
+		webSeeds:     newWebSeeds(),
+		autoSelect:   newAutoSelections(),
+		resolving:    make(map[string]bool),
+		progressMu:   &sync.Mutex{},
+		lastProgress: time.Now(),
 	}
 
 	s.configure(config)
 	s.loadTorrentFiles()
+	s.loadBans()
+	s.loadAutoSelections()
 
-	s.wg.Add(3)
+	s.wg.Add(4)
 	go s.saveResumeDataLoop()
 	go s.alertsConsumer()
 	go s.downloadProgress()
+	go s.blocklistLoop()
 
 	return s
 }
@@ -143,6 +160,17 @@ func (s *Service) alertsConsumer() {
 
 				case libtorrent.StateChangedAlertAlertType:
 					s.onStateChanged(libtorrent.SwigcptrStateChangedAlert(alertPtr))
+
+				case libtorrent.PeerBlockedAlertAlertType:
+					s.onPeerBlocked(libtorrent.SwigcptrPeerBlockedAlert(alertPtr))
+
+				case libtorrent.UrlSeedAlertAlertType:
+					s.onUrlSeed(libtorrent.SwigcptrUrlSeedAlert(alertPtr))
+
+				case libtorrent.SessionStatsAlertAlertType:
+					// Posted every progress tick, so not logged
+					s.onSessionStats(libtorrent.SwigcptrSessionStatsAlert(alertPtr))
+					continue
 				}
 
 				// log alerts
@@ -249,6 +277,55 @@ func (s *Service) saveResumeDataLoop() {
 	}
 }
 
+// saveAllResumeData requests the resume data of every torrent which needs it
+// and waits until all of them are saved or the timeout is reached. Must be
+// called after stopping the alerts consumer.
+func (s *Service) saveAllResumeData(timeout time.Duration) {
+	pending := make(map[string]bool)
+	for _, torrent := range s.torrents {
+		if torrent.handle.IsValid() {
+			status := torrent.handle.Status()
+			if status.GetHasMetadata() && status.GetNeedSaveResume() {
+				torrent.handle.SaveResumeData(libtorrent.TorrentHandleSaveInfoDict | libtorrent.TorrentHandleFlushDiskCache)
+				pending[torrent.infoHash] = true
+			}
+			libtorrent.DeleteTorrentStatus(status)
+		}
+	}
+
+	log.Infof("Saving resume data of %d torrents", len(pending))
+	deadline := time.Now().Add(timeout)
+	for len(pending) > 0 {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			log.Warningf("Timeout reached while saving resume data, %d torrents left", len(pending))
+			return
+		}
+		if s.session.WaitForAlert(remaining).Swigcptr() == 0 {
+			continue
+		}
+
+		alerts := libtorrent.NewStdVectorAlerts()
+		s.session.PopAlerts(alerts)
+		for i := 0; i < int(alerts.Size()); i++ {
+			ltAlert := alerts.Get(i)
+			switch ltAlert.Type() {
+			case libtorrent.SaveResumeDataAlertAlertType:
+				alert := libtorrent.SwigcptrSaveResumeDataAlert(ltAlert.Swigcptr())
+				s.onSaveResumeData(alert)
+				delete(pending, getHandleInfoHash(alert.GetHandle()))
+
+			case libtorrent.SaveResumeDataFailedAlertAlertType:
+				alert := libtorrent.SwigcptrSaveResumeDataFailedAlert(ltAlert.Swigcptr())
+				infoHash := getHandleInfoHash(alert.GetHandle())
+				log.Errorf("Failed saving resume data for %s: %s", infoHash, ltAlert.Message())
+				delete(pending, infoHash)
+			}
+		}
+		libtorrent.DeleteStdVectorAlerts(alerts)
+	}
+}
+
 func (s *Service) stopServices() {
 	log.Debug("Stopping LSD/DHT/UPNP/NAT-PPM")
 	s.settingsPack.SetBool("enable_lsd", false)
@@ -267,12 +344,16 @@ func (s *Service) removeTorrents() {
 
 func (s *Service) Close() {
 	log.Info("Stopping Service")
+	s.saveSessionState()
 	s.stopServices()
 
 	log.Debug("Closing service routines")
 	close(s.closing)
 	s.wg.Wait()
 
+	s.session.Pause()
+	s.saveAllResumeData(resumeDataSaveTimeout)
+
 	log.Debug("Destroying service")
 	s.removeTorrents()
 	libtorrent.DeleteSession(s.session)
@@ -287,7 +368,11 @@ func (s *Service) Reconfigure(config *settings.Settings, reset bool) {
 	createDir(config.DownloadPath)
 	createDir(config.TorrentsPath)
 
+	blocklistChanged := !reflect.DeepEqual(s.config.BlocklistSources, config.BlocklistSources)
 	s.configure(config)
+	if blocklistChanged {
+		s.ReloadBlocklist()
+	}
 
 	if reset {
 		log.Debug("Resetting torrents")
@@ -303,6 +388,7 @@ func (s *Service) configure(config *settings.Settings) {
 	logging.SetLevel(s.config.AlertsLogLevel, alertsLog.Module)
 
 	log.Info("Applying session settings")
+	s.resetOverrides()
 
 	s.UserAgent = util.UserAgent()
 	if s.config.UserAgent > 0 {
@@ -333,11 +419,19 @@ func (s *Service) configure(config *settings.Settings) {
 			log.Warning("Invalid user agent provided: using default")
 		}
 	}
+	if s.config.CustomUserAgent != "" {
+		s.UserAgent = s.config.CustomUserAgent
+	}
 	log.Infof("UserAgent: %s", s.UserAgent)
 
-	if s.config.UserAgent != settings.LibtorrentUA {
+	if s.config.UserAgent != settings.LibtorrentUA || s.config.CustomUserAgent != "" {
 		s.settingsPack.SetStr("user_agent", s.UserAgent)
 	}
+	if s.config.PeerFingerprint != nil {
+		fingerprint := generateFingerprint(s.config.PeerFingerprint)
+		log.Infof("PeerFingerprint: %s", fingerprint)
+		s.settingsPack.SetStr("peer_fingerprint", fingerprint)
+	}
 	s.settingsPack.SetInt("request_timeout", 2)
 	s.settingsPack.SetInt("peer_connect_timeout", 2)
 	s.settingsPack.SetBool("strict_end_game_mode", true)
@@ -454,7 +548,8 @@ func (s *Service) configure(config *settings.Settings) {
 	s.settingsPack.SetInt("alert_mask", int(
 		libtorrent.AlertStatusNotification|
 			libtorrent.AlertStorageNotification|
-			libtorrent.AlertErrorNotification))
+			libtorrent.AlertErrorNotification|
+			libtorrent.AlertIpBlockNotification))
 
 	// Start services
 	var listenInterfaces []string
@@ -476,15 +571,21 @@ func (s *Service) configure(config *settings.Settings) {
 		s.settingsPack.SetStr("outgoing_interfaces", outInterfaces)
 	}
 
-	s.settingsPack.SetStr("dht_bootstrap_nodes", strings.Join(DefaultDhtBootstrapNodes, ","))
+	dhtBootstrapNodes := s.config.DhtBootstrapNodes
+	if len(dhtBootstrapNodes) == 0 {
+		dhtBootstrapNodes = DefaultDhtBootstrapNodes
+	}
+	s.settingsPack.SetStr("dht_bootstrap_nodes", strings.Join(dhtBootstrapNodes, ","))
 	s.settingsPack.SetBool("enable_dht", !s.config.DisableDHT)
 	s.settingsPack.SetBool("enable_upnp", !s.config.DisableUPNP)
 	s.settingsPack.SetBool("enable_natpmp", !s.config.DisableNatPMP)
 	s.settingsPack.SetBool("enable_lsd", !s.config.DisableLSD)
 
+	s.applyOverrides()
+
 	if s.session == nil {
 		log.Debug("First configuration, starting a new session")
-		s.session = libtorrent.NewSession(s.settingsPack, libtorrent.SessionHandleAddDefaultPlugins)
+		s.session = s.newSession(s.loadSessionState())
 	} else {
 		log.Debug("Modifying session settings")
 		s.session.ApplySettings(s.settingsPack)
@@ -506,7 +607,7 @@ func (s *Service) setBufferingRateLimit(enable bool) {
 	}
 }
 
-func (s *Service) addTorrentWithParams(torrentParams libtorrent.AddTorrentParams, infoHash string, isResumeData, noDownload bool) error {
+func (s *Service) addTorrentWithParams(torrentParams libtorrent.AddTorrentParams, infoHash string, webSeeds []string, isResumeData, noDownload bool) error {
 	log.Debugf("Adding torrent params with infohash %s", infoHash)
 
 	if !isResumeData {
@@ -527,6 +628,8 @@ func (s *Service) addTorrentWithParams(torrentParams libtorrent.AddTorrentParams
 	}
 
 	if _, _, e := s.getTorrent(infoHash); e == nil {
+		// Adding the torrent again claims it, so it is not removed once resolved
+		delete(s.resolving, infoHash)
 		return DuplicateTorrentError
 	} else {
 		errorCode := libtorrent.NewErrorCode()
@@ -539,7 +642,9 @@ func (s *Service) addTorrentWithParams(torrentParams libtorrent.AddTorrentParams
 			log.Errorf("Error adding torrent '%s': %v", infoHash, errorCode.Message())
 			return LoadTorrentError
 		} else {
-			s.torrents = append(s.torrents, NewTorrent(s, torrentHandle, infoHash))
+			torrent := NewTorrent(s, torrentHandle, infoHash)
+			torrent.webSeeds = webSeeds
+			s.torrents = append(s.torrents, torrent)
 		}
 	}
 	return nil
@@ -551,6 +656,30 @@ func (s *Service) AddMagnet(magnet string, download bool) (infoHash string, err
 	return s.addMagnet(magnet, download, true)
 }
 
+// AddResolvingMagnet adds a magnet without downloading, marking it as being
+// resolved until it is claimed by adding it again. RemoveResolvedTorrent then
+// only removes the torrent if it was not claimed meanwhile.
+func (s *Service) AddResolvingMagnet(magnet string) (infoHash string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if infoHash, err = s.addMagnet(magnet, false, true); err == nil {
+		s.resolving[infoHash] = true
+	}
+	return
+}
+
+// RemoveResolvedTorrent removes, together with its files, a torrent added by
+// AddResolvingMagnet, unless it was claimed meanwhile. Returns whether the
+// torrent was removed.
+func (s *Service) RemoveResolvedTorrent(infoHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.resolving[infoHash] {
+		return false, nil
+	}
+	return true, s.removeTorrent(infoHash, true)
+}
+
 func (s *Service) addMagnet(magnet string, download, saveMagnet bool) (infoHash string, err error) {
 	log.Debugf("Adding magnet '%s' with download=%t and save=%t", magnet, download, saveMagnet)
 	torrentParams := libtorrent.NewAddTorrentParams()
@@ -564,7 +693,7 @@ func (s *Service) addMagnet(magnet string, download, saveMagnet bool) (infoHash
 	}
 
 	infoHash = getInfoHash(torrentParams.GetInfoHash())
-	err = s.addTorrentWithParams(torrentParams, infoHash, false, !download)
+	err = s.addTorrentWithParams(torrentParams, infoHash, magnetWebSeeds(magnet), false, !download)
 	if err == nil && saveMagnet {
 		if e := saveGobData(s.magnetFilePath(infoHash), Magnet{magnet, download}, 0644); e != nil {
 			log.Errorf("Failed saving magnet: %s", e)
@@ -591,7 +720,7 @@ func (s *Service) AddTorrentData(data []byte, download bool) (infoHash string, e
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	err = s.addTorrentWithParams(torrentParams, infoHash, false, !download)
+	err = s.addTorrentWithParams(torrentParams, infoHash, decodeWebSeeds(data), false, !download)
 	if err == nil {
 		if e := ioutil.WriteFile(s.torrentPath(infoHash), data, 0644); e != nil {
 			log.Errorf("Failed saving torrent: %s", e)
@@ -613,9 +742,14 @@ func (s *Service) addTorrentFile(torrentFile string, download bool) (infoHash st
 		return "", e
 	}
 
+	data, e := ioutil.ReadFile(torrentFile)
+	if e != nil {
+		return "", e
+	}
+
 	errorCode := libtorrent.NewErrorCode()
 	defer libtorrent.DeleteErrorCode(errorCode)
-	info := libtorrent.NewTorrentInfo(torrentFile, errorCode)
+	info := libtorrent.NewTorrentInfo(string(data), len(data), errorCode)
 	defer libtorrent.DeleteTorrentInfo(info)
 
 	if errorCode.Failed() {
@@ -627,7 +761,7 @@ func (s *Service) addTorrentFile(torrentFile string, download bool) (infoHash st
 	torrentParams.SetTorrentInfo(info)
 	infoHash = getInfoHash(info.InfoHash())
 
-	err = s.addTorrentWithParams(torrentParams, infoHash, false, !download)
+	err = s.addTorrentWithParams(torrentParams, infoHash, decodeWebSeeds(data), false, !download)
 	if err == nil {
 		torrentDst := s.torrentPath(infoHash)
 		if fi2, e1 := os.Stat(torrentDst); e1 != nil || !os.SameFile(fi, fi2) {
@@ -660,7 +794,7 @@ func (s *Service) addTorrentWithResumeData(fastResumeFile string) (err error) {
 				err = errors.New(errorCode.Message().(string))
 			} else {
 				infoHash := getInfoHash(torrentParams.GetInfoHash())
-				err = s.addTorrentWithParams(torrentParams, infoHash, true, false)
+				err = s.addTorrentWithParams(torrentParams, infoHash, decodeWebSeeds(fastResumeData), true, false)
 			}
 		}
 	}
@@ -677,10 +811,9 @@ func (s *Service) loadTorrentFiles() {
 
 	files, _ := filepath.Glob(s.torrentPath("*"))
 	for _, torrentFile := range files {
-		if infoHash, err := s.addTorrentFile(torrentFile, false); err == LoadTorrentError {
-			s.deletePartsFile(infoHash)
-			s.deleteFastResumeFile(infoHash)
-			s.deleteTorrentFile(infoHash)
+		if _, err := s.addTorrentFile(torrentFile, false); err == LoadTorrentError {
+			// Keep the files, so they can be inspected and repaired with fsck
+			log.Errorf("Failed loading torrent file '%s'", torrentFile)
 		}
 	}
 
@@ -702,7 +835,7 @@ func (s *Service) loadTorrentFiles() {
 	for _, partsFile := range partsFiles {
 		infoHash := strings.TrimPrefix(strings.TrimSuffix(filepath.Base(partsFile), extParts), ".")
 		if _, _, err := s.getTorrent(infoHash); err != nil {
-			log.Debugf("Cleaning up stale parts file '%s'", partsFiles)
+			log.Warningf("Cleaning up stale parts file '%s'", partsFile)
 			deleteFile(partsFile)
 		}
 	}
@@ -718,6 +851,11 @@ func (s *Service) downloadProgress() {
 		case <-s.closing:
 			return
 		case <-progressTicker.C:
+			s.progressMu.Lock()
+			s.lastProgress = time.Now()
+			s.progressMu.Unlock()
+
+			s.session.PostSessionStats()
 			if s.session.IsPaused() {
 				continue
 			}
@@ -791,6 +929,15 @@ func (s *Service) downloadProgress() {
 	}
 }
 
+// IsAlive returns whether the progress loop ran within the provided duration.
+// The loop requires the service lock, so it stops running if the service is
+// deadlocked, as well as once the service is closed.
+func (s *Service) IsAlive(within time.Duration) bool {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+	return time.Since(s.lastProgress) <= within+libtorrentProgressTime
+}
+
 func (s *Service) Pause() {
 	s.session.Pause()
 }
@@ -799,15 +946,18 @@ func (s *Service) Resume() {
 	s.session.Resume()
 }
 
-func (s *Service) GetStatus() *ServiceStatus {
+func (s *Service) GetStatus() *models.ServiceStatus {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return &ServiceStatus{
+	return &models.ServiceStatus{
 		Progress:     s.progress,
 		DownloadRate: s.downloadRate,
 		UploadRate:   s.uploadRate,
+		Downloaded:   s.downloaded,
+		Uploaded:     s.uploaded,
 		NumTorrents:  len(s.torrents),
 		IsPaused:     s.session.IsPaused(),
+		BlockedPeers: s.BlocklistStatus().BlockedPeers,
 	}
 }
 
@@ -839,14 +989,21 @@ func (s *Service) RemoveTorrent(infoHash string, removeFiles bool) error {
 	log.Debugf("Removing torrent with infohash %s and removeFiles=%t", infoHash, removeFiles)
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.removeTorrent(infoHash, removeFiles)
+}
 
+func (s *Service) removeTorrent(infoHash string, removeFiles bool) error {
 	index, torrent, err := s.getTorrent(infoHash)
 	if err == nil {
+		delete(s.resolving, infoHash)
 		s.deletePartsFile(infoHash)
 		s.deleteFastResumeFile(infoHash)
 		s.deleteTorrentFile(infoHash)
 		s.deleteMagnetFile(infoHash)
 		s.torrents = append(s.torrents[:index], s.torrents[index+1:]...)
+		s.removeTorrentBans(infoHash)
+		s.removeTorrentWebSeeds(infoHash)
+		s.removeTorrentAutoSelection(infoHash)
 		torrent.remove(removeFiles)
 	}
 
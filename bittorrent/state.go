@@ -0,0 +1,132 @@
+package bittorrent
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/i96751414/libtorrent-go"
+)
+
+const sessionStateFileName = "session.gob"
+
+var (
+	recvPayloadBytesMetric = libtorrent.FindMetricIdx("net.recv_payload_bytes")
+	sentPayloadBytesMetric = libtorrent.FindMetricIdx("net.sent_payload_bytes")
+)
+
+// SessionState is the state persisted across restarts
+type SessionState struct {
+	// Bencoded libtorrent state, containing the DHT routing table
+	Libtorrent []byte
+	// Payload bytes transferred by all the previous sessions
+	Downloaded   int64
+	Uploaded     int64
+	BlockedPeers int64
+	// Blocklist rules, used until the blocklist sources are loaded again
+	IpFilter []ipFilterRule
+}
+
+type ipFilterRule struct {
+	First net.IP
+	Last  net.IP
+}
+
+func (s *Service) sessionStateFilePath() string {
+	return filepath.Join(s.config.TorrentsPath, sessionStateFileName)
+}
+
+// loadSessionState restores the counters and ip filter rules saved by
+// saveSessionState, returning the saved libtorrent state
+func (s *Service) loadSessionState() []byte {
+	state := SessionState{}
+	if err := readGobData(s.sessionStateFilePath(), &state); err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("Failed reading session state: %s", err)
+		}
+		return nil
+	}
+
+	s.previousDownloaded = state.Downloaded
+	s.previousUploaded = state.Uploaded
+	s.downloaded = state.Downloaded
+	s.uploaded = state.Uploaded
+
+	ranges := make([]ipRange, len(state.IpFilter))
+	for i, rule := range state.IpFilter {
+		ranges[i] = ipRange{first: rule.First, last: rule.Last}
+	}
+	s.blocklist.mu.Lock()
+	s.blocklist.blockedPeers = state.BlockedPeers
+	s.blocklist.ranges = ranges
+	s.blocklist.status.Rules = len(ranges)
+	s.blocklist.mu.Unlock()
+
+	return state.Libtorrent
+}
+
+// newSession starts the libtorrent session with the saved libtorrent state, if
+// any, so the DHT does not need to bootstrap from scratch
+func (s *Service) newSession(state []byte) libtorrent.Session {
+	if len(state) > 0 {
+		params, err := readSessionParams(state)
+		if err == nil {
+			defer libtorrent.DeleteSessionParams(params)
+			params.SetSettings(s.settingsPack)
+			log.Info("Starting session with previous session state")
+			return libtorrent.NewSession(params)
+		}
+		log.Errorf("Failed loading libtorrent state: %s", err)
+	}
+	return libtorrent.NewSession(s.settingsPack, libtorrent.SessionHandleAddDefaultPlugins)
+}
+
+func readSessionParams(data []byte) (libtorrent.SessionParams, error) {
+	node := libtorrent.NewBdecodeNode()
+	defer libtorrent.DeleteBdecodeNode(node)
+	errorCode := libtorrent.NewErrorCode()
+	defer libtorrent.DeleteErrorCode(errorCode)
+	libtorrent.Bdecode(data, int64(len(data)), node, errorCode)
+	if errorCode.Failed() {
+		return nil, errors.New(errorCode.Message().(string))
+	}
+	return libtorrent.ReadSessionParams(node, libtorrent.SessionHandleSaveDhtState), nil
+}
+
+func (s *Service) onSessionStats(alert libtorrent.SessionStatsAlert) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.downloaded = s.previousDownloaded + alert.GetValue(recvPayloadBytesMetric)
+	s.uploaded = s.previousUploaded + alert.GetValue(sentPayloadBytesMetric)
+}
+
+// saveSessionState saves the session state. It must be called before
+// stopping the DHT, otherwise its routing table would be empty.
+func (s *Service) saveSessionState() {
+	entry := libtorrent.NewEntry()
+	defer libtorrent.DeleteEntry(entry)
+	s.session.SaveState(entry, libtorrent.SessionHandleSaveDhtState)
+
+	s.blocklist.mu.RLock()
+	ipFilter := make([]ipFilterRule, len(s.blocklist.ranges))
+	for i, r := range s.blocklist.ranges {
+		ipFilter[i] = ipFilterRule{First: r.first, Last: r.last}
+	}
+	blockedPeers := s.blocklist.blockedPeers
+	s.blocklist.mu.RUnlock()
+
+	s.mu.RLock()
+	state := SessionState{
+		Libtorrent:   []byte(libtorrent.Bencode(entry)),
+		Downloaded:   s.downloaded,
+		Uploaded:     s.uploaded,
+		BlockedPeers: blockedPeers,
+		IpFilter:     ipFilter,
+	}
+	s.mu.RUnlock()
+
+	if err := saveGobData(s.sessionStateFilePath(), state, 0644); err != nil {
+		log.Errorf("Failed saving session state: %s", err)
+	}
+}
@@ -2,32 +2,19 @@ package bittorrent
 
 import (
 	"bytes"
+	"net"
 	"runtime"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/i96751414/libtorrent-go"
 	"github.com/i96751414/torrest/diskusage"
+	"github.com/i96751414/torrest/models"
 	"github.com/zeebo/bencode"
 )
 
-type LTStatus int
-
-//noinspection GoUnusedConst
-const (
-	QueuedStatus             LTStatus = iota // libtorrent.TorrentStatusUnusedEnumForBackwardsCompatibility
-	CheckingStatus                           // libtorrent.TorrentStatusCheckingFiles
-	FindingStatus                            // libtorrent.TorrentStatusDownloadingMetadata
-	DownloadingStatus                        // libtorrent.TorrentStatusDownloading
-	FinishedStatus                           // libtorrent.TorrentStatusFinished
-	SeedingStatus                            // libtorrent.TorrentStatusSeeding
-	AllocatingStatus                         // libtorrent.TorrentStatusAllocating
-	CheckingResumeDataStatus                 // libtorrent.TorrentStatusCheckingResumeData
-	// Custom status
-	PausedStatus
-	BufferingStatus
-)
-
 //noinspection GoUnusedConst
 const (
 	DontDownloadPriority = uint(0)
@@ -42,40 +29,19 @@ type Torrent struct {
 	handle       libtorrent.TorrentHandle
 	infoHash     string
 	defaultName  string
+	addedTime    time.Time
 	mu           *sync.RWMutex
 	closing      chan interface{}
+	metadata     chan interface{}
 	isPaused     bool
 	files        []*File
 	spaceChecked bool
 	hasMetadata  bool
-}
-
-type TorrentInfo struct {
-	InfoHash string `json:"info_hash"`
-	Name     string `json:"name"`
-	Size     int64  `json:"size"`
-}
-
-type TorrentStatus struct {
-	Total           int64    `json:"total"`
-	TotalDone       int64    `json:"total_done"`
-	TotalWanted     int64    `json:"total_wanted"`
-	TotalWantedDone int64    `json:"total_wanted_done"`
-	Progress        float64  `json:"progress"`
-	DownloadRate    int      `json:"download_rate"`
-	UploadRate      int      `json:"upload_rate"`
-	Paused          bool     `json:"paused"`
-	HasMetadata     bool     `json:"has_metadata"`
-	State           LTStatus `json:"state"`
-	Seeders         int      `json:"seeders"`
-	SeedersTotal    int      `json:"seeders_total"`
-	Peers           int      `json:"peers"`
-	PeersTotal      int      `json:"peers_total"`
-	SeedingTime     int64    `json:"seeding_time"`
-	FinishedTime    int64    `json:"finished_time"`
-	ActiveTime      int64    `json:"active_time"`
-	AllTimeDownload int64    `json:"all_time_download"`
-	AllTimeUpload   int64    `json:"all_time_upload"`
+	// Mirrors the handle url seeds, as libtorrent-go does not expose them
+	webSeeds []string
+	// Pieces requested by the readers
+	piecesMu      *sync.Mutex
+	pieceRequests map[int]*pieceRequest
 }
 
 type TorrentFileRaw struct {
@@ -108,9 +74,14 @@ func NewTorrent(service *Service, handle libtorrent.TorrentHandle, infoHash stri
 		handle:      handle,
 		infoHash:    infoHash,
 		defaultName: name,
+		addedTime:   time.Unix(int64(status.GetAddedTime()), 0),
 		mu:          &sync.RWMutex{},
 		closing:     make(chan interface{}),
+		metadata:    make(chan interface{}),
 		isPaused:    paused,
+
+		piecesMu:      &sync.Mutex{},
+		pieceRequests: make(map[int]*pieceRequest),
 	}
 
 	if status.GetHasMetadata() {
@@ -135,15 +106,69 @@ func (t *Torrent) onMetadataReceived() {
 	}
 
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	previous := t.files
 	t.files = f
-	t.hasMetadata = true
+	received := !t.hasMetadata
+	if received {
+		t.hasMetadata = true
+		close(t.metadata)
+	}
+	t.mu.Unlock()
+
+	for _, file := range previous {
+		file.close()
+	}
+	if received {
+		t.service.onTorrentMetadata(t)
+	}
 }
 
 func (t *Torrent) InfoHash() string {
 	return t.infoHash
 }
 
+// AddedTime returns the time when the torrent was first added to the session
+func (t *Torrent) AddedTime() time.Time {
+	return t.addedTime
+}
+
+// ConnectPeer connects the torrent to the peer at the given address, in
+// the host:port format
+func (t *Torrent) ConnectPeer(address string) error {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return InvalidAddressError
+	}
+	ip := net.ParseIP(host)
+	port, err := strconv.ParseUint(portString, 10, 16)
+	if ip == nil || err != nil || port == 0 {
+		return InvalidAddressError
+	}
+
+	log.Debugf("Connecting torrent %s to peer %s", t.infoHash, address)
+	peerAddress := libtorrent.MakeAddress(ip.String())
+	defer libtorrent.DeleteAddress(peerAddress)
+	endpoint := libtorrent.NewTcpEndpoint(peerAddress, uint16(port))
+	defer libtorrent.DeleteTcpEndpoint(endpoint)
+	t.handle.ConnectPeer(endpoint)
+	return nil
+}
+
+// BanPeer bans the peer ip address from this torrent
+func (t *Torrent) BanPeer(ip string) error {
+	return t.service.banPeer(t.infoHash, ip, true)
+}
+
+// UnbanPeer removes the torrent ban of the peer ip address
+func (t *Torrent) UnbanPeer(ip string) error {
+	return t.service.banPeer(t.infoHash, ip, false)
+}
+
+// Bans returns the peers banned from this torrent
+func (t *Torrent) Bans() []string {
+	return t.service.torrentBans(t.infoHash)
+}
+
 func (t *Torrent) Pause() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -159,40 +184,40 @@ func (t *Torrent) Resume() {
 	t.isPaused = false
 }
 
-func (t *Torrent) getState(file ...*File) LTStatus {
+func (t *Torrent) getState(file ...*File) models.LTStatus {
 	if t.isPaused {
-		return PausedStatus
+		return models.PausedStatus
 	}
 	if hasFlagsUint64(t.handle.Flags(), libtorrent.GetPaused()|libtorrent.GetAutoManaged()) {
-		return QueuedStatus
+		return models.QueuedStatus
 	}
 	if !t.hasMetadata {
-		return FindingStatus
+		return models.FindingStatus
 	}
 
 	status := t.handle.Status()
 	defer libtorrent.DeleteTorrentStatus(status)
-	state := LTStatus(status.GetState())
+	state := models.LTStatus(status.GetState())
 
-	if state == DownloadingStatus {
+	if state == models.DownloadingStatus {
 		downloading := false
 		for _, f := range file {
 			if f.isBuffering {
-				return BufferingStatus
+				return models.BufferingStatus
 			}
 			if f.priority != DontDownloadPriority {
 				downloading = true
 			}
 		}
 		if !downloading || t.getFilesProgress(file...) == 100 {
-			return FinishedStatus
+			return models.FinishedStatus
 		}
 	}
 
 	return state
 }
 
-func (t *Torrent) GetState() LTStatus {
+func (t *Torrent) GetState() models.LTStatus {
 	return t.getState(t.files...)
 }
 
@@ -200,8 +225,8 @@ func (t *Torrent) HasMetadata() bool {
 	return t.hasMetadata
 }
 
-func (t *Torrent) GetInfo() *TorrentInfo {
-	torrentInfo := &TorrentInfo{InfoHash: t.infoHash}
+func (t *Torrent) GetInfo() *models.TorrentInfo {
+	torrentInfo := &models.TorrentInfo{InfoHash: t.infoHash, SelectedFile: t.service.selectedFile(t.infoHash)}
 	if info := t.handle.TorrentFile(); info.Swigcptr() != 0 {
 		torrentInfo.Name = info.Name()
 		torrentInfo.Size = info.TotalSize()
@@ -211,7 +236,7 @@ func (t *Torrent) GetInfo() *TorrentInfo {
 	return torrentInfo
 }
 
-func (t *Torrent) GetStatus() *TorrentStatus {
+func (t *Torrent) GetStatus() *models.TorrentStatus {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 	status := t.handle.Status()
@@ -229,7 +254,7 @@ func (t *Torrent) GetStatus() *TorrentStatus {
 		peersTotal = peers
 	}
 
-	return &TorrentStatus{
+	return &models.TorrentStatus{
 		Total:           status.GetTotal(),
 		TotalDone:       status.GetTotalDone(),
 		TotalWanted:     status.GetTotalWanted(),
@@ -252,6 +277,12 @@ func (t *Torrent) GetStatus() *TorrentStatus {
 	}
 }
 
+func (t *Torrent) downloadRate() int {
+	status := t.handle.Status()
+	defer libtorrent.DeleteTorrentStatus(status)
+	return status.GetDownloadRate()
+}
+
 func (t *Torrent) Files() ([]*File, error) {
 	if !t.hasMetadata {
 		return nil, NoMetadataError
@@ -381,6 +412,11 @@ func (t *Torrent) checkAvailableSpace() {
 
 func (t *Torrent) remove(removeFiles bool) {
 	close(t.closing)
+	t.mu.RLock()
+	for _, file := range t.files {
+		file.close()
+	}
+	t.mu.RUnlock()
 
 	var flags uint
 	if removeFiles {
@@ -4,6 +4,8 @@ import (
 	"encoding/gob"
 	"io"
 	"os"
+
+	"github.com/i96751414/torrest/settings"
 )
 
 func deleteFile(path string) {
@@ -54,6 +56,36 @@ func containsInt(arr []int, value int) bool {
 	return false
 }
 
+func containsString(arr []string, value string) bool {
+	for _, a := range arr {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+// generateFingerprint generates the peer id prefix the same way as libtorrent
+// generate_fingerprint, e.g. -LT1200-
+func generateFingerprint(fingerprint *settings.PeerFingerprint) string {
+	versionChar := func(v int) byte {
+		switch {
+		case v < 10:
+			return '0' + byte(v)
+		case v < 36:
+			return 'A' + byte(v-10)
+		default:
+			return 'a' + byte(v-36)
+		}
+	}
+	return "-" + fingerprint.ClientId + string([]byte{
+		versionChar(fingerprint.Major),
+		versionChar(fingerprint.Minor),
+		versionChar(fingerprint.Revision),
+		versionChar(fingerprint.Tag),
+	}) + "-"
+}
+
 func saveGobData(path string, data interface{}, perm os.FileMode) error {
 	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
 	if err != nil {
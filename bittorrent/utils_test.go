@@ -0,0 +1,26 @@
+package bittorrent
+
+import (
+	"testing"
+
+	"github.com/i96751414/torrest/settings"
+)
+
+func TestGenerateFingerprint(t *testing.T) {
+	tests := []struct {
+		fingerprint settings.PeerFingerprint
+		expected    string
+	}{
+		{fingerprint: settings.PeerFingerprint{ClientId: "LT", Major: 1, Minor: 2, Revision: 0, Tag: 0}, expected: "-LT1200-"},
+		{fingerprint: settings.PeerFingerprint{ClientId: "qB", Major: 4, Minor: 3, Revision: 9, Tag: 0}, expected: "-qB4390-"},
+		{fingerprint: settings.PeerFingerprint{ClientId: "UT", Major: 10, Minor: 35, Revision: 36, Tag: 61}, expected: "-UTAZaz-"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			if fingerprint := generateFingerprint(&test.fingerprint); fingerprint != test.expected {
+				t.Fatalf("expected %s, got %s", test.expected, fingerprint)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package bittorrent
+
+import (
+	"context"
+	"time"
+
+	"github.com/i96751414/torrest/models"
+)
+
+const waitRefreshDuration = 500 * time.Millisecond
+
+func contextError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return TimeoutError
+	}
+	return ctx.Err()
+}
+
+// WaitForMetadata blocks until the torrent metadata is received, the torrent
+// is removed or the context is done
+func (t *Torrent) WaitForMetadata(ctx context.Context) error {
+	select {
+	case <-t.metadata:
+		return nil
+	case <-t.closing:
+		return TorrentClosedError
+	case <-ctx.Done():
+		return contextError(ctx)
+	}
+}
+
+// waitFor periodically checks the condition until it holds, the torrent is
+// removed or the context is done
+func (t *Torrent) waitFor(ctx context.Context, condition func() bool) error {
+	if condition() {
+		return nil
+	}
+
+	ticker := time.NewTicker(waitRefreshDuration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if condition() {
+				return nil
+			}
+		case <-t.closing:
+			return TorrentClosedError
+		case <-ctx.Done():
+			return contextError(ctx)
+		}
+	}
+}
+
+// WaitForStateChange blocks until the torrent state is different from the
+// provided one
+func (t *Torrent) WaitForStateChange(ctx context.Context, from models.LTStatus) error {
+	return t.waitFor(ctx, func() bool {
+		return t.GetState() != from
+	})
+}
+
+// WaitForBuffering blocks until the file is no longer buffering
+func (f *File) WaitForBuffering(ctx context.Context) error {
+	return f.torrent.waitFor(ctx, func() bool {
+		f.mu.RLock()
+		defer f.mu.RUnlock()
+		return !f.isBuffering
+	})
+}
+
+// WaitForCompletion blocks until the file is completely downloaded
+func (f *File) WaitForCompletion(ctx context.Context) error {
+	return f.torrent.waitFor(ctx, func() bool {
+		return f.BytesCompleted() == f.length
+	})
+}
@@ -0,0 +1,154 @@
+package bittorrent
+
+import (
+	"bytes"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/i96751414/libtorrent-go"
+	"github.com/zeebo/bencode"
+)
+
+// Libtorrent retries failed web seeds after urlseed_wait_retry seconds (30 by
+// default), so a web seed without errors for longer than this is considered
+// to have recovered
+const webSeedErrorExpiration = 2 * time.Minute
+
+type webSeedError struct {
+	message string
+	time    time.Time
+}
+
+type webSeeds struct {
+	mu sync.RWMutex
+	// Last error of each web seed, per torrent
+	errors map[string]map[string]webSeedError
+}
+
+func newWebSeeds() *webSeeds {
+	return &webSeeds{errors: make(map[string]map[string]webSeedError)}
+}
+
+type urlList struct {
+	UrlList interface{} `bencode:"url-list"`
+}
+
+// decodeWebSeeds returns the web seeds of the url-list key of a .torrent
+// file or resume data
+func decodeWebSeeds(data []byte) []string {
+	list := &urlList{}
+	if err := bencode.NewDecoder(bytes.NewReader(data)).Decode(list); err != nil {
+		log.Warningf("Failed decoding url-list: %s", err)
+	}
+	webSeeds := []string{}
+	// The url-list may either be a single url or a list of urls
+	switch l := list.UrlList.(type) {
+	case string:
+		if l != "" {
+			webSeeds = append(webSeeds, l)
+		}
+	case []interface{}:
+		for _, u := range l {
+			if s, ok := u.(string); ok && s != "" && !containsString(webSeeds, s) {
+				webSeeds = append(webSeeds, s)
+			}
+		}
+	}
+	return webSeeds
+}
+
+// magnetWebSeeds returns the web seeds (ws parameters) of a magnet uri
+func magnetWebSeeds(magnet string) []string {
+	webSeeds := []string{}
+	if u, err := url.Parse(magnet); err == nil {
+		for _, s := range u.Query()["ws"] {
+			if s != "" && !containsString(webSeeds, s) {
+				webSeeds = append(webSeeds, s)
+			}
+		}
+	}
+	return webSeeds
+}
+
+// removeTorrentWebSeeds discards the web seeds errors of a removed torrent
+func (s *Service) removeTorrentWebSeeds(infoHash string) {
+	s.webSeeds.mu.Lock()
+	defer s.webSeeds.mu.Unlock()
+	delete(s.webSeeds.errors, infoHash)
+}
+
+func (s *Service) onUrlSeed(alert libtorrent.UrlSeedAlert) {
+	infoHash := getHandleInfoHash(alert.GetHandle())
+	s.webSeeds.mu.Lock()
+	defer s.webSeeds.mu.Unlock()
+	if s.webSeeds.errors[infoHash] == nil {
+		s.webSeeds.errors[infoHash] = make(map[string]webSeedError)
+	}
+	s.webSeeds.errors[infoHash][alert.ServerUrl()] = webSeedError{message: alert.ErrorMessage(), time: time.Now()}
+}
+
+// ValidateWebSeed checks if the url can be used as a web seed
+func ValidateWebSeed(webSeed string) error {
+	// Libtorrent web seeds only support the http protocol, so ftp is not
+	// supported either
+	if u, err := url.Parse(webSeed); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return InvalidWebSeedError
+	}
+	return nil
+}
+
+// saveWebSeeds requests the torrent resume data, which persists its web seeds.
+// Torrents without metadata have no resume data, so their web seeds changes
+// are only persisted once the metadata is received.
+func (t *Torrent) saveWebSeeds() {
+	if t.HasMetadata() {
+		t.handle.SaveResumeData(libtorrent.TorrentHandleSaveInfoDict)
+	}
+}
+
+// AddWebSeed adds a http web seed (BEP 19) to the torrent
+func (t *Torrent) AddWebSeed(webSeed string) error {
+	if err := ValidateWebSeed(webSeed); err != nil {
+		return err
+	}
+	log.Infof("Adding web seed %s to torrent %s", webSeed, t.infoHash)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !containsString(t.webSeeds, webSeed) {
+		t.handle.AddUrlSeed(webSeed)
+		t.webSeeds = append(t.webSeeds, webSeed)
+		t.saveWebSeeds()
+	}
+	return nil
+}
+
+// RemoveWebSeed removes a web seed from the torrent
+func (t *Torrent) RemoveWebSeed(webSeed string) error {
+	log.Infof("Removing web seed %s from torrent %s", webSeed, t.infoHash)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, s := range t.webSeeds {
+		if s == webSeed {
+			t.handle.RemoveUrlSeed(webSeed)
+			t.webSeeds = append(t.webSeeds[:i], t.webSeeds[i+1:]...)
+			t.saveWebSeeds()
+
+			w := t.service.webSeeds
+			w.mu.Lock()
+			delete(w.errors[t.infoHash], webSeed)
+			w.mu.Unlock()
+			return nil
+		}
+	}
+	return InvalidWebSeedError
+}
+
+// WebSeeds returns the torrent web seeds urls
+func (t *Torrent) WebSeeds() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return append([]string{}, t.webSeeds...)
+}
@@ -0,0 +1,251 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/i96751414/torrest/models"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	unixScheme     = "unix://"
+)
+
+// Client talks to a running torrest daemon through its REST API
+type Client struct {
+	baseUrl    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the daemon at the provided base url, e.g.
+// http://localhost:8080, or unix:///run/torrest.sock for a daemon listening on
+// a Unix domain socket
+func NewClient(baseUrl string) *Client {
+	httpClient := &http.Client{Timeout: requestTimeout}
+	if strings.HasPrefix(baseUrl, unixScheme) {
+		socketPath := strings.TrimPrefix(baseUrl, unixScheme)
+		httpClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		}
+		baseUrl = "http://unix"
+	}
+	return &Client{
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Url returns the absolute url of the provided path
+func (c *Client) Url(path string, query url.Values) string {
+	u := c.baseUrl + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (c *Client) do(req *http.Request, v interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		errorResponse := models.ErrorResponse{}
+		if json.Unmarshal(body, &errorResponse) == nil && errorResponse.Error != "" {
+			return errors.New(errorResponse.Error)
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if v != nil {
+		return json.Unmarshal(body, v)
+	}
+	return nil
+}
+
+func (c *Client) get(path string, query url.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.Url(path, query), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, v)
+}
+
+func (c *Client) post(path string, query url.Values, contentType string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, c.Url(path, query), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.do(req, v)
+}
+
+func torrentPath(infoHash, action string) string {
+	return "/torrents/" + url.PathEscape(infoHash) + "/" + action
+}
+
+func fileUrlPath(infoHash string, file int, action string) string {
+	return torrentPath(infoHash, "files/"+strconv.Itoa(file)+"/"+action)
+}
+
+func addQuery(download, ignoreDuplicate bool) url.Values {
+	return url.Values{
+		"download":         {strconv.FormatBool(download)},
+		"ignore_duplicate": {strconv.FormatBool(ignoreDuplicate)},
+	}
+}
+
+// Status returns the service status
+func (c *Client) Status() (status *models.ServiceStatus, err error) {
+	err = c.get("/status", nil, &status)
+	return
+}
+
+// Pause pauses the service
+func (c *Client) Pause() error {
+	return c.get("/pause", nil, nil)
+}
+
+// Resume resumes the service
+func (c *Client) Resume() error {
+	return c.get("/resume", nil, nil)
+}
+
+// AddMagnet adds a magnet uri and returns its info hash
+func (c *Client) AddMagnet(magnet string, download, ignoreDuplicate bool) (string, error) {
+	query := addQuery(download, ignoreDuplicate)
+	query.Set("uri", magnet)
+	response := models.NewTorrentResponse{}
+	err := c.get("/add/magnet", query, &response)
+	return response.InfoHash, err
+}
+
+// AddTorrentFile uploads a torrent file and returns its info hash
+func (c *Client) AddTorrentFile(path string, download, ignoreDuplicate bool) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("torrent", filepath.Base(path))
+	if err == nil {
+		if _, err = part.Write(data); err == nil {
+			err = writer.Close()
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	response := models.NewTorrentResponse{}
+	err = c.post("/add/torrent", addQuery(download, ignoreDuplicate), writer.FormDataContentType(), body, &response)
+	return response.InfoHash, err
+}
+
+// Add adds either a magnet uri or a local torrent file
+func (c *Client) Add(source string, download, ignoreDuplicate bool) (string, error) {
+	if strings.HasPrefix(source, "magnet:") {
+		return c.AddMagnet(source, download, ignoreDuplicate)
+	}
+	if _, err := os.Stat(source); err != nil {
+		return "", err
+	}
+	return c.AddTorrentFile(source, download, ignoreDuplicate)
+}
+
+// Torrents lists all torrents, optionally with their status
+func (c *Client) Torrents(status bool) (torrents []models.TorrentInfoResponse, err error) {
+	err = c.get("/torrents/", url.Values{"status": {strconv.FormatBool(status)}}, &torrents)
+	return
+}
+
+// TorrentStatus returns the status of a torrent
+func (c *Client) TorrentStatus(infoHash string) (status *models.TorrentStatus, err error) {
+	err = c.get(torrentPath(infoHash, "status"), nil, &status)
+	return
+}
+
+// TorrentMetadata returns the detailed metadata of a torrent
+func (c *Client) TorrentMetadata(infoHash string) (metadata *models.TorrentMetadata, err error) {
+	err = c.get(torrentPath(infoHash, "metadata"), nil, &metadata)
+	return
+}
+
+// Magnet returns the magnet uri of a torrent, optionally including its trackers
+func (c *Client) Magnet(infoHash string, trackers bool) (string, error) {
+	response := models.MagnetResponse{}
+	err := c.get(torrentPath(infoHash, "magnet"), url.Values{"trackers": {strconv.FormatBool(trackers)}}, &response)
+	return response.Magnet, err
+}
+
+// RemoveTorrent removes a torrent, optionally deleting its files
+func (c *Client) RemoveTorrent(infoHash string, deleteFiles bool) error {
+	return c.get(torrentPath(infoHash, "remove"), url.Values{"delete": {strconv.FormatBool(deleteFiles)}}, nil)
+}
+
+// PauseTorrent pauses a torrent
+func (c *Client) PauseTorrent(infoHash string) error {
+	return c.get(torrentPath(infoHash, "pause"), nil, nil)
+}
+
+// ResumeTorrent resumes a torrent
+func (c *Client) ResumeTorrent(infoHash string) error {
+	return c.get(torrentPath(infoHash, "resume"), nil, nil)
+}
+
+// Files lists the files of a torrent, optionally with their status
+func (c *Client) Files(infoHash string, status bool) (files []models.FileInfoResponse, err error) {
+	err = c.get(torrentPath(infoHash, "files"), url.Values{"status": {strconv.FormatBool(status)}}, &files)
+	return
+}
+
+// ServeUrl returns the url where the file is served
+func (c *Client) ServeUrl(infoHash string, file int) string {
+	return c.Url(fileUrlPath(infoHash, file, "serve"), nil)
+}
+
+// Settings returns the raw settings JSON object
+func (c *Client) Settings() (settings json.RawMessage, err error) {
+	err = c.get("/settings/get", nil, &settings)
+	return
+}
+
+// SettingsSources returns where each effective setting value came from
+func (c *Client) SettingsSources() (sources map[string]string, err error) {
+	err = c.get("/settings/sources", nil, &sources)
+	return
+}
+
+// SetSettings updates the settings with the provided JSON object, returning
+// the resulting settings
+func (c *Client) SetSettings(data []byte, reset bool) (settings json.RawMessage, err error) {
+	err = c.post("/settings/set", url.Values{"reset": {strconv.FormatBool(reset)}},
+		"application/json", bytes.NewReader(data), &settings)
+	return
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	"github.com/i96751414/torrest/client"
+)
+
+const defaultDaemonUrl = "http://localhost:8080"
+
+type command struct {
+	usage       string
+	description string
+	run         func(args []string) int
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"fsck":      {"[-fix] [-json]", "check the torrents and download paths consistency", fsckCommand},
+		"add":       {"[-download] [-ignore-duplicate] <magnet|torrent file>...", "add torrents", addCommand},
+		"ls":        {"", "list torrents", lsCommand},
+		"status":    {"[info hash]", "show service or torrent status", statusCommand},
+		"rm":        {"[-delete] <info hash>...", "remove torrents", rmCommand},
+		"pause":     {"[info hash]...", "pause service or torrents", pauseCommand},
+		"resume":    {"[info hash]...", "resume service or torrents", resumeCommand},
+		"files":     {"<info hash>", "list torrent files", filesCommand},
+		"serve-url": {"<info hash> <file id>", "print the url where a file is served", serveUrlCommand},
+		"settings":  {"get | sources | set [-reset] <json|@file|->", "get or set daemon settings", settingsCommand},
+	}
+}
+
+// runCommand runs the subcommand provided in args, if any
+func runCommand(args []string) (exitCode int, ok bool) {
+	if len(args) > 0 {
+		if cmd, exists := commands[args[0]]; exists {
+			return cmd.run(args[1:]), true
+		}
+	}
+	return 0, false
+}
+
+func commandsUsage() {
+	out := flag.CommandLine.Output()
+	_, _ = fmt.Fprintf(out, "Usage: %s [flags]\n       %s <command> [flags] [arguments]\n\nFlags:\n", os.Args[0], os.Args[0])
+	flag.PrintDefaults()
+	_, _ = fmt.Fprintln(out, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", name, commands[name].description)
+	}
+	_ = w.Flush()
+}
+
+type clientFlags struct {
+	*flag.FlagSet
+	url        string
+	jsonOutput bool
+}
+
+func newClientFlags(name string) *clientFlags {
+	f := &clientFlags{FlagSet: flag.NewFlagSet(name, flag.ExitOnError)}
+	defaultUrl := os.Getenv("TORREST_URL")
+	if defaultUrl == "" {
+		defaultUrl = defaultDaemonUrl
+	}
+	f.StringVar(&f.url, "url", defaultUrl, "Daemon url (or TORREST_URL environment variable)")
+	f.BoolVar(&f.jsonOutput, "json", false, "Print the output in JSON format")
+	f.Usage = func() {
+		_, _ = fmt.Fprintf(f.Output(), "Usage: %s %s [flags] %s\n\n%s\n\nFlags:\n",
+			os.Args[0], name, commands[name].usage, commands[name].description)
+		f.PrintDefaults()
+	}
+	return f
+}
+
+func (f *clientFlags) parse(args []string, minArgs, maxArgs int) *client.Client {
+	_ = f.Parse(args)
+	if f.NArg() < minArgs || (maxArgs >= 0 && f.NArg() > maxArgs) {
+		f.Usage()
+		os.Exit(2)
+	}
+	return client.NewClient(f.url)
+}
+
+func commandError(err error) int {
+	_, _ = fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	return 1
+}
+
+func printJSON(v interface{}) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return commandError(err)
+	}
+	return 0
+}
+
+func printTable(header []string, rows [][]string) int {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return commandError(err)
+	}
+	return 0
+}
+
+func bytesString(size int64) string {
+	return humanize.Bytes(uint64(size))
+}
+
+func rateString(rate int64) string {
+	return humanize.Bytes(uint64(rate)) + "/s"
+}
+
+func percentString(progress float64) string {
+	return strconv.FormatFloat(progress, 'f', 1, 64) + "%"
+}
+
+func addCommand(args []string) int {
+	f := newClientFlags("add")
+	download := f.Bool("download", false, "Start downloading all files")
+	ignoreDuplicate := f.Bool("ignore-duplicate", false, "Do not fail if the torrent was already added")
+	c := f.parse(args, 1, -1)
+
+	var infoHashes []string
+	for _, source := range f.Args() {
+		infoHash, err := c.Add(source, *download, *ignoreDuplicate)
+		if err != nil {
+			return commandError(fmt.Errorf("%s: %s", source, err))
+		}
+		infoHashes = append(infoHashes, infoHash)
+	}
+
+	if f.jsonOutput {
+		return printJSON(infoHashes)
+	}
+	fmt.Println(strings.Join(infoHashes, "\n"))
+	return 0
+}
+
+func lsCommand(args []string) int {
+	f := newClientFlags("ls")
+	c := f.parse(args, 0, 0)
+
+	torrents, err := c.Torrents(true)
+	if err != nil {
+		return commandError(err)
+	}
+	if f.jsonOutput {
+		return printJSON(torrents)
+	}
+
+	rows := make([][]string, len(torrents))
+	for i, t := range torrents {
+		rows[i] = []string{t.InfoHash, t.Name, bytesString(t.Size), "-", "-", "-", "-", "-"}
+		if t.Status != nil {
+			rows[i][3] = t.Status.State.String()
+			rows[i][4] = percentString(t.Status.Progress)
+			rows[i][5] = rateString(int64(t.Status.DownloadRate))
+			rows[i][6] = rateString(int64(t.Status.UploadRate))
+			rows[i][7] = fmt.Sprintf("%d/%d", t.Status.Seeders+t.Status.Peers, t.Status.SeedersTotal+t.Status.PeersTotal)
+		}
+	}
+	return printTable([]string{"INFO HASH", "NAME", "SIZE", "STATE", "PROGRESS", "DOWN", "UP", "PEERS"}, rows)
+}
+
+func statusCommand(args []string) int {
+	f := newClientFlags("status")
+	c := f.parse(args, 0, 1)
+
+	if f.NArg() == 0 {
+		status, err := c.Status()
+		if err != nil {
+			return commandError(err)
+		}
+		if f.jsonOutput {
+			return printJSON(status)
+		}
+		return printTable([]string{"TORRENTS", "PROGRESS", "DOWN", "UP", "PAUSED"}, [][]string{{
+			strconv.Itoa(status.NumTorrents), percentString(status.Progress), rateString(status.DownloadRate),
+			rateString(status.UploadRate), strconv.FormatBool(status.IsPaused),
+		}})
+	}
+
+	status, err := c.TorrentStatus(f.Arg(0))
+	if err != nil {
+		return commandError(err)
+	}
+	if f.jsonOutput {
+		return printJSON(status)
+	}
+	return printTable([]string{"STATE", "PROGRESS", "DONE", "WANTED", "DOWN", "UP", "SEEDERS", "PEERS"}, [][]string{{
+		status.State.String(), percentString(status.Progress), bytesString(status.TotalWantedDone),
+		bytesString(status.TotalWanted), rateString(int64(status.DownloadRate)), rateString(int64(status.UploadRate)),
+		fmt.Sprintf("%d/%d", status.Seeders, status.SeedersTotal), fmt.Sprintf("%d/%d", status.Peers, status.PeersTotal),
+	}})
+}
+
+func rmCommand(args []string) int {
+	f := newClientFlags("rm")
+	deleteFiles := f.Bool("delete", false, "Delete the downloaded files")
+	c := f.parse(args, 1, -1)
+
+	for _, infoHash := range f.Args() {
+		if err := c.RemoveTorrent(infoHash, *deleteFiles); err != nil {
+			return commandError(fmt.Errorf("%s: %s", infoHash, err))
+		}
+	}
+	return 0
+}
+
+func pauseCommand(args []string) int {
+	f := newClientFlags("pause")
+	c := f.parse(args, 0, -1)
+
+	if f.NArg() == 0 {
+		if err := c.Pause(); err != nil {
+			return commandError(err)
+		}
+	}
+	for _, infoHash := range f.Args() {
+		if err := c.PauseTorrent(infoHash); err != nil {
+			return commandError(fmt.Errorf("%s: %s", infoHash, err))
+		}
+	}
+	return 0
+}
+
+func resumeCommand(args []string) int {
+	f := newClientFlags("resume")
+	c := f.parse(args, 0, -1)
+
+	if f.NArg() == 0 {
+		if err := c.Resume(); err != nil {
+			return commandError(err)
+		}
+	}
+	for _, infoHash := range f.Args() {
+		if err := c.ResumeTorrent(infoHash); err != nil {
+			return commandError(fmt.Errorf("%s: %s", infoHash, err))
+		}
+	}
+	return 0
+}
+
+func filesCommand(args []string) int {
+	f := newClientFlags("files")
+	c := f.parse(args, 1, 1)
+
+	files, err := c.Files(f.Arg(0), true)
+	if err != nil {
+		return commandError(err)
+	}
+	if f.jsonOutput {
+		return printJSON(files)
+	}
+
+	rows := make([][]string, len(files))
+	for i, file := range files {
+		rows[i] = []string{strconv.Itoa(file.Id), file.Path, bytesString(file.Length), "-", "-", "-"}
+		if file.Status != nil {
+			rows[i][3] = file.Status.State.String()
+			rows[i][4] = percentString(file.Status.Progress)
+			rows[i][5] = strconv.FormatUint(uint64(file.Status.Priority), 10)
+		}
+	}
+	return printTable([]string{"ID", "PATH", "SIZE", "STATE", "PROGRESS", "PRIORITY"}, rows)
+}
+
+func serveUrlCommand(args []string) int {
+	f := newClientFlags("serve-url")
+	c := f.parse(args, 2, 2)
+
+	file, err := strconv.Atoi(f.Arg(1))
+	if err != nil {
+		return commandError(fmt.Errorf("invalid file id '%s'", f.Arg(1)))
+	}
+	serveUrl := c.ServeUrl(f.Arg(0), file)
+	if f.jsonOutput {
+		return printJSON(map[string]string{"url": serveUrl})
+	}
+	fmt.Println(serveUrl)
+	return 0
+}
+
+func settingsCommand(args []string) int {
+	f := newClientFlags("settings")
+	reset := f.Bool("reset", false, "Reset torrents after setting the settings")
+	// Flags may be given either before or after the action, as in
+	// settings set -reset <json>
+	_ = f.Parse(args)
+	action, actionArgs := f.Arg(0), f.Args()
+	if len(actionArgs) > 0 {
+		actionArgs = actionArgs[1:]
+	}
+	c := f.parse(actionArgs, 0, 1)
+
+	var settings json.RawMessage
+	var err error
+	switch {
+	case action == "get" && f.NArg() == 0:
+		settings, err = c.Settings()
+	case action == "sources" && f.NArg() == 0:
+		var sources map[string]string
+		if sources, err = c.SettingsSources(); err != nil {
+			return commandError(err)
+		} else if f.jsonOutput {
+			return printJSON(sources)
+		}
+		paths := make([]string, 0, len(sources))
+		for path := range sources {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		rows := make([][]string, len(paths))
+		for i, path := range paths {
+			rows[i] = []string{path, sources[path]}
+		}
+		return printTable([]string{"SETTING", "SOURCE"}, rows)
+	case action == "set" && f.NArg() == 1:
+		var data []byte
+		if data, err = readArgument(f.Arg(0)); err == nil {
+			settings, err = c.SetSettings(data, *reset)
+		}
+	default:
+		f.Usage()
+		return 2
+	}
+
+	if err != nil {
+		return commandError(err)
+	}
+	// Settings are always printed in JSON format
+	return printJSON(settings)
+}
+
+// readArgument returns the argument itself, the contents of the file if
+// prefixed with @, or the standard input if -
+func readArgument(arg string) ([]byte, error) {
+	switch {
+	case arg == "-":
+		return ioutil.ReadAll(os.Stdin)
+	case strings.HasPrefix(arg, "@"):
+		return ioutil.ReadFile(arg[1:])
+	default:
+		return []byte(arg), nil
+	}
+}
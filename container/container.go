@@ -0,0 +1,71 @@
+// Package container parses the top level structure of media containers, in
+// order to find the regions of a file a player needs before starting playback
+package container
+
+import (
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Maximum number of top level elements parsed before giving up
+const maxElements = 1024
+
+var (
+	UnsupportedContainerError = errors.New("unsupported container")
+	InvalidContainerError     = errors.New("invalid container")
+)
+
+// Range represents a byte range of a file
+type Range struct {
+	Offset int64
+	Length int64
+}
+
+// Info contains the regions of a media file needed to start playing it
+type Info struct {
+	// Ranges holds the metadata and index regions of the file
+	Ranges []Range
+	// MediaOffset is the offset where the media data starts
+	MediaOffset int64
+	// Duration is the media duration, or 0 if unknown
+	Duration time.Duration
+}
+
+// Parse parses the media container of the file with the given name and size,
+// reading only the required parts through r
+func Parse(r io.ReaderAt, size int64, name string) (*Info, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".mp4", ".m4v", ".m4a", ".mov", ".3gp":
+		return parseMP4(r, size)
+	case ".mkv", ".mka", ".webm":
+		return parseMKV(r, size)
+	}
+	return nil, UnsupportedContainerError
+}
+
+// readFull reads len(b) bytes at off, failing if the file is not big enough
+func readFull(r io.ReaderAt, b []byte, off, size int64) error {
+	if off < 0 || off+int64(len(b)) > size {
+		return InvalidContainerError
+	}
+	n, err := r.ReadAt(b, off)
+	if n == len(b) {
+		return nil
+	} else if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readError returns err if it is an error of the underlying reader, which must
+// be returned to the caller so the file can be parsed again later, or nil if it
+// is due to an invalid structure
+func readError(err error) error {
+	if err == InvalidContainerError {
+		return nil
+	}
+	return err
+}
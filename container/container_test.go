@@ -0,0 +1,49 @@
+package container
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+var errUnavailable = errors.New("data not available")
+
+// partialReader simulates a file which is only partially downloaded, failing
+// reads beyond the available bytes
+type partialReader struct {
+	data      []byte
+	available int64
+}
+
+func (r *partialReader) ReadAt(b []byte, off int64) (int, error) {
+	if off+int64(len(b)) > r.available {
+		return 0, errUnavailable
+	}
+	return copy(b, r.data[off:]), nil
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func be32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func be64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func TestParseUnsupported(t *testing.T) {
+	if _, err := Parse(&partialReader{}, 0, "video.avi"); err != UnsupportedContainerError {
+		t.Fatalf("expected error %v, got %v", UnsupportedContainerError, err)
+	}
+}
@@ -0,0 +1,274 @@
+package container
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Matroska element ids, as defined in https://www.matroska.org/technical/elements.html
+const (
+	ebmlId         = 0x1A45DFA3
+	segmentId      = 0x18538067
+	seekHeadId     = 0x114D9B74
+	seekId         = 0x4DBB
+	seekIdId       = 0x53AB
+	seekPositionId = 0x53AC
+	infoId         = 0x1549A966
+	timescaleId    = 0x2AD7B1
+	durationId     = 0x4489
+	cuesId         = 0x1C53BB6B
+	clusterId      = 0x1F43B675
+)
+
+// Maximum size of the elements read as a whole
+const maxElementSize = 1024 * 1024
+
+const unknownSize = -1
+
+type ebmlElement struct {
+	id         uint64
+	offset     int64
+	headerSize int64
+	dataSize   int64
+}
+
+func (e *ebmlElement) dataOffset() int64 {
+	return e.offset + e.headerSize
+}
+
+func (e *ebmlElement) size() int64 {
+	return e.headerSize + e.dataSize
+}
+
+// parseMKV walks the top level elements of a Matroska segment until the first
+// cluster. The elements before it (SeekHead, Info, Tracks, ...) are needed,
+// and so are the Cues, which are usually at the end and located through the
+// SeekHead.
+func parseMKV(r io.ReaderAt, size int64) (*Info, error) {
+	header, err := readElementHeader(r, 0, size)
+	if err != nil {
+		return nil, err
+	} else if header.id != ebmlId || header.dataSize == unknownSize {
+		return nil, InvalidContainerError
+	}
+
+	segment, err := readElementHeader(r, header.size(), size)
+	if err != nil {
+		return nil, err
+	} else if segment.id != segmentId {
+		return nil, InvalidContainerError
+	}
+	segmentEnd := size
+	if segment.dataSize != unknownSize && segment.dataOffset()+segment.dataSize < size {
+		segmentEnd = segment.dataOffset() + segment.dataSize
+	}
+
+	info := &Info{MediaOffset: -1, Ranges: []Range{{Offset: 0, Length: segment.dataOffset()}}}
+	hasCues := false
+	var cuesPositions []int64
+
+	for off, count := segment.dataOffset(), 0; off < segmentEnd && count < maxElements; count++ {
+		element, err := readElementHeader(r, off, segmentEnd)
+		if err != nil {
+			return nil, err
+		}
+		if element.id == clusterId {
+			info.MediaOffset = off
+			break
+		}
+		if element.dataSize == unknownSize || element.dataSize > segmentEnd-element.dataOffset() {
+			return nil, InvalidContainerError
+		}
+
+		info.Ranges = append(info.Ranges, Range{Offset: off, Length: element.size()})
+		switch element.id {
+		case seekHeadId:
+			positions, err := readSeekHead(r, element)
+			if err != nil {
+				return nil, err
+			}
+			for _, position := range positions[cuesId] {
+				cuesPositions = append(cuesPositions, segment.dataOffset()+position)
+			}
+		case infoId:
+			if info.Duration, err = readSegmentDuration(r, element); err != nil {
+				return nil, err
+			}
+		case cuesId:
+			hasCues = true
+		}
+		off += element.size()
+	}
+
+	if info.MediaOffset < 0 {
+		return nil, InvalidContainerError
+	}
+
+	if !hasCues {
+		for _, position := range cuesPositions {
+			cues, err := readElementHeader(r, position, segmentEnd)
+			if err != nil {
+				if err = readError(err); err != nil {
+					return nil, err
+				}
+			} else if cues.id == cuesId && cues.dataSize != unknownSize && cues.dataSize <= segmentEnd-cues.dataOffset() {
+				info.Ranges = append(info.Ranges, Range{Offset: position, Length: cues.size()})
+				break
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// readSeekHead returns the positions, relative to the segment data, of each
+// element referenced by the SeekHead
+func readSeekHead(r io.ReaderAt, seekHead *ebmlElement) (map[uint64][]int64, error) {
+	data, err := readElementData(r, seekHead)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[uint64][]int64)
+	for len(data) > 0 {
+		seek, err := parseElementHeader(data)
+		if err != nil || seek.dataSize == unknownSize || seek.size() > int64(len(data)) {
+			return nil, InvalidContainerError
+		}
+
+		if seek.id == seekId {
+			var id uint64
+			position := int64(-1)
+			for seekData := data[seek.headerSize:seek.size()]; len(seekData) > 0; {
+				child, err := parseElementHeader(seekData)
+				if err != nil || child.dataSize == unknownSize || child.size() > int64(len(seekData)) {
+					return nil, InvalidContainerError
+				}
+				value := readUint(seekData[child.headerSize:child.size()])
+				switch child.id {
+				case seekIdId:
+					id = value
+				case seekPositionId:
+					position = int64(value)
+				}
+				seekData = seekData[child.size():]
+			}
+			if id != 0 && position >= 0 {
+				positions[id] = append(positions[id], position)
+			}
+		}
+		data = data[seek.size():]
+	}
+	return positions, nil
+}
+
+// readSegmentDuration returns the duration stored in the segment Info element,
+// or 0 if not found. Only errors of the reader are returned.
+func readSegmentDuration(r io.ReaderAt, segmentInfo *ebmlElement) (time.Duration, error) {
+	data, err := readElementData(r, segmentInfo)
+	if err != nil {
+		return 0, readError(err)
+	}
+
+	timescale := uint64(1000000)
+	var duration float64
+	for len(data) > 0 {
+		child, err := parseElementHeader(data)
+		if err != nil || child.dataSize == unknownSize || child.size() > int64(len(data)) {
+			return 0, nil
+		}
+		value := data[child.headerSize:child.size()]
+		switch child.id {
+		case timescaleId:
+			timescale = readUint(value)
+		case durationId:
+			switch len(value) {
+			case 4:
+				duration = float64(math.Float32frombits(binary.BigEndian.Uint32(value)))
+			case 8:
+				duration = math.Float64frombits(binary.BigEndian.Uint64(value))
+			}
+		}
+		data = data[child.size():]
+	}
+	return time.Duration(duration * float64(timescale)), nil
+}
+
+func readElementData(r io.ReaderAt, element *ebmlElement) ([]byte, error) {
+	if element.dataSize > maxElementSize {
+		return nil, InvalidContainerError
+	}
+	data := make([]byte, element.dataSize)
+	return data, readFull(r, data, element.dataOffset(), element.dataOffset()+element.dataSize)
+}
+
+func readElementHeader(r io.ReaderAt, off, size int64) (*ebmlElement, error) {
+	// Ids have at most 4 bytes and sizes 8 bytes
+	buf := make([]byte, 12)
+	if size-off < int64(len(buf)) {
+		buf = buf[:max(size-off, 0)]
+	}
+	if err := readFull(r, buf, off, size); err != nil {
+		return nil, err
+	}
+	element, err := parseElementHeader(buf)
+	if err != nil {
+		return nil, err
+	}
+	element.offset = off
+	return element, nil
+}
+
+func parseElementHeader(b []byte) (*ebmlElement, error) {
+	id, idLength, err := parseVint(b, true)
+	if err != nil || idLength > 4 {
+		return nil, InvalidContainerError
+	}
+	dataSize, sizeLength, err := parseVint(b[idLength:], false)
+	if err != nil {
+		return nil, err
+	}
+
+	element := &ebmlElement{id: id, headerSize: int64(idLength + sizeLength), dataSize: int64(dataSize)}
+	if dataSize == 1<<(7*sizeLength)-1 {
+		element.dataSize = unknownSize
+	}
+	return element, nil
+}
+
+// parseVint parses an EBML variable size integer. Ids keep the length marker
+// bit while sizes do not.
+func parseVint(b []byte, keepMarker bool) (uint64, int, error) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0, InvalidContainerError
+	}
+	length := bits.LeadingZeros8(b[0]) + 1
+	if length > len(b) {
+		return 0, 0, InvalidContainerError
+	}
+	value := uint64(b[0])
+	if !keepMarker {
+		value &= 0xff >> length
+	}
+	for i := 1; i < length; i++ {
+		value = value<<8 | uint64(b[i])
+	}
+	return value, length, nil
+}
+
+func readUint(b []byte) (value uint64) {
+	for _, c := range b {
+		value = value<<8 | uint64(c)
+	}
+	return
+}
+
+func max(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
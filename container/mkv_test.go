@@ -0,0 +1,102 @@
+package container
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ebmlSize(size int) []byte {
+	if size < 0x7f {
+		return []byte{0x80 | byte(size)}
+	}
+	return []byte{0x40 | byte(size>>8), byte(size)}
+}
+
+func idBytes(id uint64) []byte {
+	b := be64(id)
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+func element(id uint64, children ...[]byte) []byte {
+	data := concat(children...)
+	return concat(idBytes(id), ebmlSize(len(data)), data)
+}
+
+func TestParseMKV(t *testing.T) {
+	header := element(ebmlId, element(0x4286, []byte{1}))
+	// Segment with unknown size
+	segmentHeader := concat(idBytes(segmentId), []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	info := element(infoId, element(timescaleId, []byte{0x0f, 0x42, 0x40}), element(durationId, be64(math.Float64bits(5000))))
+	cluster := element(clusterId, element(0xE7, []byte{0}), make([]byte, 64))
+	cues := element(cuesId, element(0xBB, make([]byte, 8)))
+	seekHead := func(position int) []byte {
+		return element(seekHeadId, element(seekId, element(seekIdId, idBytes(cuesId)), element(seekPositionId, be32(uint32(position)))))
+	}
+	cuesPosition := len(seekHead(0)) + len(info) + len(cluster)
+	segmentOffset := len(header) + len(segmentHeader)
+	file := concat(header, segmentHeader, seekHead(cuesPosition), info, cluster, cues)
+	clusterOffset := int64(segmentOffset + len(seekHead(0)) + len(info))
+
+	expected := &Info{
+		Ranges: []Range{
+			{0, int64(segmentOffset)},
+			{int64(segmentOffset), int64(len(seekHead(0)))},
+			{int64(segmentOffset + len(seekHead(0))), int64(len(info))},
+			{int64(segmentOffset + cuesPosition), int64(len(cues))},
+		},
+		MediaOffset: clusterOffset,
+		Duration:    5 * time.Second,
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		available int
+		info      *Info
+		err       error
+	}{
+		{name: "cues at end", data: file, info: expected},
+		{
+			name: "cues not found",
+			data: concat(header, segmentHeader, seekHead(cuesPosition+1), info, cluster, cues),
+			info: &Info{Ranges: expected.Ranges[:3], MediaOffset: clusterOffset, Duration: 5 * time.Second},
+		},
+		{name: "missing cluster", data: concat(header, segmentHeader, info), err: InvalidContainerError},
+		{name: "not ebml", data: concat(segmentHeader, info, cluster), err: InvalidContainerError},
+		{name: "truncated header", data: header[:3], err: InvalidContainerError},
+		{
+			name:      "info unavailable",
+			data:      file,
+			available: segmentOffset + len(seekHead(0)) + 4,
+			err:       errUnavailable,
+		},
+		{
+			name:      "cues unavailable",
+			data:      file,
+			available: segmentOffset + cuesPosition,
+			err:       errUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			available := int64(len(test.data))
+			if test.available > 0 {
+				available = int64(test.available)
+			}
+			r := &partialReader{data: test.data, available: available}
+			info, err := Parse(r, int64(len(test.data)), "video.mkv")
+			if err != test.err {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if !reflect.DeepEqual(info, test.info) {
+				t.Fatalf("expected info %+v, got %+v", test.info, info)
+			}
+		})
+	}
+}
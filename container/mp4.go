@@ -0,0 +1,124 @@
+package container
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// parseMP4 walks the top level boxes of an ISO base media file. Besides the
+// moov box (which may be either at the start or at the end of the file), the
+// ftyp, sidx and mfra boxes are also needed by most players.
+func parseMP4(r io.ReaderAt, size int64) (*Info, error) {
+	info := &Info{MediaOffset: -1}
+	hasMovie := false
+	header := make([]byte, 16)
+
+	for off, count := int64(0), 0; off < size && count < maxElements; count++ {
+		if err := readFull(r, header[:8], off, size); err != nil {
+			return nil, err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = size - off
+		case 1:
+			if err := readFull(r, header[8:16], off+8, size); err != nil {
+				return nil, err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if !isBoxType(boxType) || boxSize < headerSize || boxSize > size-off {
+			return nil, InvalidContainerError
+		}
+
+		switch boxType {
+		case "ftyp", "sidx", "mfra":
+			info.Ranges = append(info.Ranges, Range{Offset: off, Length: boxSize})
+		case "moov":
+			info.Ranges = append(info.Ranges, Range{Offset: off, Length: boxSize})
+			duration, err := readMovieDuration(r, off+headerSize, off+boxSize)
+			if err != nil {
+				return nil, err
+			}
+			info.Duration = duration
+			hasMovie = true
+		case "mdat":
+			if info.MediaOffset < 0 {
+				info.MediaOffset = off + headerSize
+			}
+		case "moof":
+			if info.MediaOffset < 0 {
+				info.MediaOffset = off
+			}
+		}
+
+		// Fragmented files have boxes all over the file, so stop as soon as
+		// possible instead of waiting for all of them
+		if hasMovie && info.MediaOffset >= 0 {
+			return info, nil
+		}
+		off += boxSize
+	}
+
+	if !hasMovie || info.MediaOffset < 0 {
+		return nil, InvalidContainerError
+	}
+	return info, nil
+}
+
+// readMovieDuration looks for the mvhd box inside the moov box, returning 0 if
+// the duration is not found. Only errors of the reader are returned.
+func readMovieDuration(r io.ReaderAt, off, end int64) (time.Duration, error) {
+	header := make([]byte, 8)
+	for count := 0; off < end && count < maxElements; count++ {
+		if err := readFull(r, header, off, end); err != nil {
+			return 0, readError(err)
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		if boxSize < 8 {
+			return 0, nil
+		}
+		if string(header[4:8]) != "mvhd" {
+			off += boxSize
+			continue
+		}
+
+		// version(1) flags(3) and then 32 or 64 bits times, depending on version
+		data := make([]byte, 32)
+		if err := readFull(r, data[:4], off+8, end); err != nil {
+			return 0, readError(err)
+		}
+		var timescale, duration uint64
+		if data[0] == 1 {
+			if err := readFull(r, data[:28], off+12, end); err != nil {
+				return 0, readError(err)
+			}
+			timescale = uint64(binary.BigEndian.Uint32(data[16:20]))
+			duration = binary.BigEndian.Uint64(data[20:28])
+		} else {
+			if err := readFull(r, data[:16], off+12, end); err != nil {
+				return 0, readError(err)
+			}
+			timescale = uint64(binary.BigEndian.Uint32(data[8:12]))
+			duration = uint64(binary.BigEndian.Uint32(data[12:16]))
+		}
+		if timescale == 0 || duration == 0xffffffff || duration == 0xffffffffffffffff {
+			return 0, nil
+		}
+		return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+	}
+	return 0, nil
+}
+
+func isBoxType(boxType string) bool {
+	for i := 0; i < len(boxType); i++ {
+		if boxType[i] < 0x20 || boxType[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
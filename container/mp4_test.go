@@ -0,0 +1,110 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func box(boxType string, payload ...[]byte) []byte {
+	data := concat(payload...)
+	return concat(be32(uint32(8+len(data))), []byte(boxType), data)
+}
+
+func largeBox(boxType string, payload ...[]byte) []byte {
+	data := concat(payload...)
+	return concat(be32(1), []byte(boxType), be64(uint64(16+len(data))), data)
+}
+
+func mvhd(timescale, duration uint32) []byte {
+	return box("mvhd", be32(0), be32(0), be32(0), be32(timescale), be32(duration))
+}
+
+func mvhdV1(timescale uint32, duration uint64) []byte {
+	return box("mvhd", []byte{1, 0, 0, 0}, be64(0), be64(0), be32(timescale), be64(duration))
+}
+
+func TestParseMP4(t *testing.T) {
+	ftyp := box("ftyp", []byte("isom"), be32(0))
+	moov := box("moov", mvhd(1000, 90000))
+	mdat := box("mdat", make([]byte, 32))
+	largeMoov := largeBox("moov", box("trak"), mvhdV1(600, 600*120))
+
+	tests := []struct {
+		name      string
+		data      []byte
+		available int
+		info      *Info
+		err       error
+	}{
+		{
+			name: "moov at start",
+			data: concat(ftyp, moov, mdat),
+			info: &Info{
+				Ranges:      []Range{{0, int64(len(ftyp))}, {int64(len(ftyp)), int64(len(moov))}},
+				MediaOffset: int64(len(ftyp)+len(moov)) + 8,
+				Duration:    90 * time.Second,
+			},
+		},
+		{
+			name: "moov at end",
+			data: concat(ftyp, mdat, moov),
+			info: &Info{
+				Ranges:      []Range{{0, int64(len(ftyp))}, {int64(len(ftyp) + len(mdat)), int64(len(moov))}},
+				MediaOffset: int64(len(ftyp)) + 8,
+				Duration:    90 * time.Second,
+			},
+		},
+		{
+			name: "64 bit box with version 1 mvhd",
+			data: concat(ftyp, largeMoov, mdat),
+			info: &Info{
+				Ranges:      []Range{{0, int64(len(ftyp))}, {int64(len(ftyp)), int64(len(largeMoov))}},
+				MediaOffset: int64(len(ftyp)+len(largeMoov)) + 8,
+				Duration:    120 * time.Second,
+			},
+		},
+		{
+			name: "unknown duration",
+			data: concat(ftyp, box("moov", mvhd(0, 100)), mdat),
+			info: &Info{
+				Ranges:      []Range{{0, int64(len(ftyp))}, {int64(len(ftyp)), int64(len(moov))}},
+				MediaOffset: int64(len(ftyp)+len(moov)) + 8,
+			},
+		},
+		{name: "missing moov", data: concat(ftyp, mdat), err: InvalidContainerError},
+		{name: "missing mdat", data: concat(ftyp, moov), err: InvalidContainerError},
+		{name: "invalid box type", data: concat(ftyp, box("mo\x00v"), mdat), err: InvalidContainerError},
+		{name: "box bigger than file", data: concat(ftyp, moov, mdat)[:len(ftyp)+len(moov)+10], err: InvalidContainerError},
+		{name: "truncated file", data: ftyp[:6], err: InvalidContainerError},
+		{
+			name:      "moov header unavailable",
+			data:      concat(ftyp, mdat, moov),
+			available: len(ftyp) + len(mdat) + 4,
+			err:       errUnavailable,
+		},
+		{
+			name:      "mvhd unavailable",
+			data:      concat(ftyp, moov, mdat),
+			available: len(ftyp) + 16,
+			err:       errUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			available := int64(len(test.data))
+			if test.available > 0 {
+				available = int64(test.available)
+			}
+			r := &partialReader{data: test.data, available: available}
+			info, err := Parse(r, int64(len(test.data)), "video.mp4")
+			if err != test.err {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if !reflect.DeepEqual(info, test.info) {
+				t.Fatalf("expected info %+v, got %+v", test.info, info)
+			}
+		})
+	}
+}
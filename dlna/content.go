@@ -0,0 +1,246 @@
+package dlna
+
+import (
+	"encoding/xml"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/i96751414/torrest/bittorrent"
+	"github.com/i96751414/torrest/util"
+)
+
+const (
+	rootObjectId  = "0"
+	dlnaFlags     = "DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01700000000000000000000000000000"
+	folderClass   = "object.container.storageFolder"
+	videoClass    = "object.item.videoItem"
+	audioClass    = "object.item.audioItem.musicTrack"
+	imageClass    = "object.item.imageItem.photo"
+	objectIdSplit = "/"
+)
+
+// mediaType returns the mime type and the upnp class of the file, or empty
+// strings if it is not a media file
+func mediaType(name string) (mimeType string, class string) {
+	mimeType = util.MimeTypeByName(name)
+	switch {
+	case strings.HasPrefix(mimeType, "video/"):
+		class = videoClass
+	case strings.HasPrefix(mimeType, "audio/"):
+		class = audioClass
+	case strings.HasPrefix(mimeType, "image/"):
+		class = imageClass
+	default:
+		mimeType = ""
+	}
+	return
+}
+
+type didlLite struct {
+	XMLName    xml.Name        `xml:"DIDL-Lite"`
+	Xmlns      string          `xml:"xmlns,attr"`
+	XmlnsDC    string          `xml:"xmlns:dc,attr"`
+	XmlnsUPnP  string          `xml:"xmlns:upnp,attr"`
+	XmlnsDLNA  string          `xml:"xmlns:dlna,attr"`
+	Containers []didlContainer `xml:"container"`
+	Items      []didlItem      `xml:"item"`
+}
+
+type didlContainer struct {
+	Id         string `xml:"id,attr"`
+	ParentId   string `xml:"parentID,attr"`
+	Restricted int    `xml:"restricted,attr"`
+	Searchable int    `xml:"searchable,attr"`
+	ChildCount int    `xml:"childCount,attr"`
+	Title      string `xml:"dc:title"`
+	Class      string `xml:"upnp:class"`
+}
+
+type didlItem struct {
+	Id         string       `xml:"id,attr"`
+	ParentId   string       `xml:"parentID,attr"`
+	Restricted int          `xml:"restricted,attr"`
+	Title      string       `xml:"dc:title"`
+	Class      string       `xml:"upnp:class"`
+	Res        didlResource `xml:"res"`
+}
+
+type didlResource struct {
+	ProtocolInfo string `xml:"protocolInfo,attr"`
+	Size         int64  `xml:"size,attr"`
+	URL          string `xml:",chardata"`
+}
+
+func newDidlLite() *didlLite {
+	return &didlLite{
+		Xmlns:     "urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/",
+		XmlnsDC:   "http://purl.org/dc/elements/1.1/",
+		XmlnsUPnP: "urn:schemas-upnp-org:metadata-1-0/upnp/",
+		XmlnsDLNA: "urn:schemas-dlna-org:metadata-1-0/",
+	}
+}
+
+func (d *didlLite) count() int {
+	return len(d.Containers) + len(d.Items)
+}
+
+// slice keeps only the requested window of objects, with containers first
+func (d *didlLite) slice(start, count int) {
+	total := d.count()
+	if start > total {
+		start = total
+	}
+	end := total
+	if count > 0 && start+count < total {
+		end = start + count
+	}
+
+	containers := len(d.Containers)
+	if start < containers {
+		d.Items = d.Items[:max(0, end-containers)]
+		d.Containers = d.Containers[start:min(end, containers)]
+	} else {
+		d.Items = d.Items[start-containers : end-containers]
+		d.Containers = nil
+	}
+}
+
+func (d *didlLite) marshal() (string, error) {
+	data, err := xml.Marshal(d)
+	return string(data), err
+}
+
+func newTorrentContainer(torrent *bittorrent.Torrent) didlContainer {
+	return didlContainer{
+		Id:         torrent.InfoHash(),
+		ParentId:   rootObjectId,
+		Restricted: 1,
+		ChildCount: len(mediaFiles(torrent)),
+		Title:      torrent.GetInfo().Name,
+		Class:      folderClass,
+	}
+}
+
+func newFileItem(torrent *bittorrent.Torrent, file *bittorrent.File, baseURL string) didlItem {
+	mimeType, class := mediaType(file.Name())
+	return didlItem{
+		Id:         torrent.InfoHash() + objectIdSplit + strconv.Itoa(file.Id()),
+		ParentId:   torrent.InfoHash(),
+		Restricted: 1,
+		Title:      file.Name(),
+		Class:      class,
+		Res: didlResource{
+			ProtocolInfo: "http-get:*:" + mimeType + ":" + dlnaFlags,
+			Size:         file.Length(),
+			URL:          baseURL + "/torrents/" + torrent.InfoHash() + "/files/" + strconv.Itoa(file.Id()) + "/serve",
+		},
+	}
+}
+
+func mediaFiles(torrent *bittorrent.Torrent) []*bittorrent.File {
+	var result []*bittorrent.File
+	files, _ := torrent.Files()
+	for _, file := range files {
+		if mimeType, _ := mediaType(file.Name()); mimeType != "" {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// browse implements the ContentDirectory Browse action. Torrents are listed
+// as containers of the root object and their media files as items.
+func (m *MediaServer) browse(objectId, browseFlag, baseURL string) (*didlLite, error) {
+	didl := newDidlLite()
+	parts := strings.SplitN(objectId, objectIdSplit, 2)
+
+	switch browseFlag {
+	case "BrowseMetadata":
+		if objectId == rootObjectId {
+			didl.Containers = append(didl.Containers, didlContainer{
+				Id:         rootObjectId,
+				ParentId:   "-1",
+				Restricted: 1,
+				ChildCount: len(m.service.Torrents()),
+				Title:      m.friendlyName,
+				Class:      folderClass,
+			})
+			return didl, nil
+		}
+		torrent, err := m.service.GetTorrent(parts[0])
+		if err != nil {
+			return nil, noSuchObjectError
+		}
+		if len(parts) == 1 {
+			didl.Containers = append(didl.Containers, newTorrentContainer(torrent))
+			return didl, nil
+		}
+		file, err := getMediaFile(torrent, parts[1])
+		if err != nil {
+			return nil, err
+		}
+		didl.Items = append(didl.Items, newFileItem(torrent, file, baseURL))
+		return didl, nil
+
+	case "BrowseDirectChildren":
+		if objectId == rootObjectId {
+			for _, torrent := range m.service.Torrents() {
+				didl.Containers = append(didl.Containers, newTorrentContainer(torrent))
+			}
+			return didl, nil
+		}
+		torrent, err := m.service.GetTorrent(parts[0])
+		if err != nil || len(parts) != 1 {
+			return nil, noSuchObjectError
+		}
+		for _, file := range mediaFiles(torrent) {
+			didl.Items = append(didl.Items, newFileItem(torrent, file, baseURL))
+		}
+		return didl, nil
+	}
+
+	return nil, invalidArgsError
+}
+
+func getMediaFile(torrent *bittorrent.Torrent, id string) (*bittorrent.File, error) {
+	fileId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, noSuchObjectError
+	}
+	file, err := torrent.GetFile(fileId)
+	if err != nil {
+		return nil, noSuchObjectError
+	}
+	if mimeType, _ := mediaType(file.Name()); mimeType == "" {
+		return nil, noSuchObjectError
+	}
+	return file, nil
+}
+
+// systemUpdateId changes whenever torrents are added, removed or receive
+// their metadata, so that control points know when to refresh
+func (m *MediaServer) systemUpdateId() uint32 {
+	h := fnv.New32a()
+	for _, torrent := range m.service.Torrents() {
+		_, _ = h.Write([]byte(torrent.InfoHash()))
+		if torrent.HasMetadata() {
+			_, _ = h.Write([]byte{1})
+		}
+	}
+	return h.Sum32()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
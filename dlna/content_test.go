@@ -0,0 +1,57 @@
+package dlna
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDidlLiteSlice(t *testing.T) {
+	tests := []struct {
+		name       string
+		start      int
+		count      int
+		containers []string
+		items      []string
+	}{
+		{name: "all", start: 0, count: 0, containers: []string{"c0", "c1", "c2"}, items: []string{"i0", "i1", "i2", "i3"}},
+		{name: "count bigger than total", start: 0, count: 100, containers: []string{"c0", "c1", "c2"}, items: []string{"i0", "i1", "i2", "i3"}},
+		{name: "containers only", start: 0, count: 2, containers: []string{"c0", "c1"}, items: []string{}},
+		{name: "containers and items", start: 1, count: 3, containers: []string{"c1", "c2"}, items: []string{"i0"}},
+		{name: "items only", start: 4, count: 2, containers: []string{}, items: []string{"i1", "i2"}},
+		{name: "remaining items", start: 5, count: 0, containers: []string{}, items: []string{"i2", "i3"}},
+		{name: "start at end", start: 7, count: 5, containers: []string{}, items: []string{}},
+		{name: "start after end", start: 10, count: 5, containers: []string{}, items: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := newDidlLite()
+			for i := 0; i < 3; i++ {
+				d.Containers = append(d.Containers, didlContainer{Id: "c" + strconv.Itoa(i)})
+			}
+			for i := 0; i < 4; i++ {
+				d.Items = append(d.Items, didlItem{Id: "i" + strconv.Itoa(i)})
+			}
+
+			d.slice(test.start, test.count)
+			containers := []string{}
+			for _, c := range d.Containers {
+				containers = append(containers, c.Id)
+			}
+			items := []string{}
+			for _, i := range d.Items {
+				items = append(items, i.Id)
+			}
+			if !reflect.DeepEqual(containers, test.containers) {
+				t.Errorf("expected containers %v, got %v", test.containers, containers)
+			}
+			if !reflect.DeepEqual(items, test.items) {
+				t.Errorf("expected items %v, got %v", test.items, items)
+			}
+			if d.count() != len(test.containers)+len(test.items) {
+				t.Errorf("expected count %d, got %d", len(test.containers)+len(test.items), d.count())
+			}
+		})
+	}
+}
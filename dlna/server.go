@@ -0,0 +1,298 @@
+package dlna
+
+import (
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/i96751414/torrest/bittorrent"
+	"github.com/i96751414/torrest/util"
+	"github.com/op/go-logging"
+)
+
+var log = logging.MustGetLogger("dlna")
+
+var deviceDescription = template.Must(template.New("device").Parse(deviceDescriptionTemplate))
+
+// upnpError represents an UPnP error returned on SOAP faults
+type upnpError struct {
+	Code        int
+	Description string
+}
+
+func (e *upnpError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Description)
+}
+
+var (
+	invalidActionError = &upnpError{401, "Invalid Action"}
+	invalidArgsError   = &upnpError{402, "Invalid Args"}
+	noSuchObjectError  = &upnpError{701, "No such object"}
+)
+
+// MediaServer is an UPnP AV MediaServer exposing the torrents media files to
+// DLNA renderers. Resources are served by the REST api serve endpoint.
+type MediaServer struct {
+	service      *bittorrent.Service
+	uuid         string
+	friendlyName string
+	ssdp         *ssdpResponder
+}
+
+// NewMediaServer creates a media server for the service, whose http server is
+// listening on httpPort
+func NewMediaServer(service *bittorrent.Service, httpPort int) *MediaServer {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	// Keep the same UUID between restarts, so renderers recognize the server
+	sum := md5.Sum([]byte("torrest:" + hostname + ":" + strconv.Itoa(httpPort)))
+	uuid := fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+
+	m := &MediaServer{
+		service:      service,
+		uuid:         uuid,
+		friendlyName: "Torrest (" + hostname + ")",
+	}
+	m.ssdp = newSSDPResponder(uuid, httpPort)
+	return m
+}
+
+// Start starts announcing the media server on the network
+func (m *MediaServer) Start() error {
+	log.Infof("Starting DLNA media server with uuid %s", m.uuid)
+	return m.ssdp.start()
+}
+
+// Close stops announcing the media server
+func (m *MediaServer) Close() {
+	log.Info("Stopping DLNA media server")
+	m.ssdp.close()
+}
+
+func (m *MediaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case deviceDescriptionPath:
+		w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+		if err := deviceDescription.Execute(w, map[string]string{
+			"FriendlyName": m.friendlyName,
+			"Version":      util.GetVersion(),
+			"UUID":         m.uuid,
+		}); err != nil {
+			log.Errorf("Failed writing device description: %s", err)
+		}
+	case contentDirectorySCPDPath:
+		serveXML(w, contentDirectorySCPD)
+	case connectionManagerSCPDPath:
+		serveXML(w, connectionManagerSCPD)
+	case contentDirectoryControl:
+		m.serveControl(w, r, contentDirectoryServiceType, m.contentDirectoryAction)
+	case connectionManagerControl:
+		m.serveControl(w, r, connectionManagerServiceType, m.connectionManagerAction)
+	case contentDirectoryEvent, connectionManagerEvent:
+		serveSubscription(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func serveXML(w http.ResponseWriter, data string) {
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	if _, err := io.WriteString(w, data); err != nil {
+		log.Errorf("Failed writing xml: %s", err)
+	}
+}
+
+// serveSubscription accepts event subscriptions without ever sending events,
+// as some renderers refuse to browse servers which reject them
+func serveSubscription(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "SUBSCRIBE":
+		sid := r.Header.Get("SID")
+		if sid == "" {
+			sum := md5.Sum([]byte(r.RemoteAddr + r.Header.Get("CALLBACK")))
+			sid = fmt.Sprintf("uuid:%x", sum)
+		}
+		w.Header().Set("SID", sid)
+		w.Header().Set("TIMEOUT", "Second-"+strconv.Itoa(ssdpMaxAge))
+		w.WriteHeader(http.StatusOK)
+	case "UNSUBSCRIBE":
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+type soapAction func(action string, args map[string]string, r *http.Request) ([][2]string, error)
+
+func (m *MediaServer) serveControl(w http.ResponseWriter, r *http.Request, serviceType string, handler soapAction) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	soapAction := strings.Trim(r.Header.Get("SOAPACTION"), `"`)
+	sep := strings.LastIndex(soapAction, "#")
+	if sep < 0 || soapAction[:sep] != serviceType {
+		writeSOAPFault(w, invalidActionError)
+		return
+	}
+	action := soapAction[sep+1:]
+
+	args, err := parseSOAPArgs(r.Body)
+	if err != nil {
+		log.Debugf("Failed parsing SOAP request: %s", err)
+		writeSOAPFault(w, invalidArgsError)
+		return
+	}
+
+	result, err := handler(action, args, r)
+	if err != nil {
+		log.Debugf("SOAP action %s failed: %s", action, err)
+		writeSOAPFault(w, err)
+		return
+	}
+
+	var body strings.Builder
+	body.WriteString(`<u:` + action + `Response xmlns:u="` + serviceType + `">`)
+	for _, arg := range result {
+		body.WriteString("<" + arg[0] + ">")
+		_ = xml.EscapeText(&body, []byte(arg[1]))
+		body.WriteString("</" + arg[0] + ">")
+	}
+	body.WriteString(`</u:` + action + `Response>`)
+	writeSOAPEnvelope(w, http.StatusOK, body.String())
+}
+
+func (m *MediaServer) contentDirectoryAction(action string, args map[string]string, r *http.Request) ([][2]string, error) {
+	switch action {
+	case "GetSearchCapabilities":
+		return [][2]string{{"SearchCaps", ""}}, nil
+	case "GetSortCapabilities":
+		return [][2]string{{"SortCaps", ""}}, nil
+	case "GetSystemUpdateID":
+		return [][2]string{{"Id", strconv.FormatUint(uint64(m.systemUpdateId()), 10)}}, nil
+	case "Browse":
+		startingIndex, e1 := strconv.Atoi(args["StartingIndex"])
+		requestedCount, e2 := strconv.Atoi(args["RequestedCount"])
+		if e1 != nil || e2 != nil || startingIndex < 0 || requestedCount < 0 {
+			return nil, invalidArgsError
+		}
+
+		didl, err := m.browse(args["ObjectID"], args["BrowseFlag"], "http://"+r.Host)
+		if err != nil {
+			return nil, err
+		}
+		totalMatches := didl.count()
+		didl.slice(startingIndex, requestedCount)
+		result, err := didl.marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		return [][2]string{
+			{"Result", result},
+			{"NumberReturned", strconv.Itoa(didl.count())},
+			{"TotalMatches", strconv.Itoa(totalMatches)},
+			{"UpdateID", strconv.FormatUint(uint64(m.systemUpdateId()), 10)},
+		}, nil
+	}
+	return nil, invalidActionError
+}
+
+func (m *MediaServer) connectionManagerAction(action string, args map[string]string, _ *http.Request) ([][2]string, error) {
+	switch action {
+	case "GetProtocolInfo":
+		var source []string
+		for _, mimeType := range util.MediaMimeTypes() {
+			source = append(source, "http-get:*:"+mimeType+":*")
+		}
+		sort.Strings(source)
+		return [][2]string{{"Source", strings.Join(source, ",")}, {"Sink", ""}}, nil
+	case "GetCurrentConnectionIDs":
+		return [][2]string{{"ConnectionIDs", "0"}}, nil
+	case "GetCurrentConnectionInfo":
+		if args["ConnectionID"] != "0" {
+			return nil, invalidArgsError
+		}
+		return [][2]string{
+			{"RcsID", "-1"},
+			{"AVTransportID", "-1"},
+			{"ProtocolInfo", ""},
+			{"PeerConnectionManager", ""},
+			{"PeerConnectionID", "-1"},
+			{"Direction", "Output"},
+			{"Status", "OK"},
+		}, nil
+	}
+	return nil, invalidActionError
+}
+
+// parseSOAPArgs returns the arguments of the action contained in the body
+// of the SOAP envelope
+func parseSOAPArgs(body io.Reader) (map[string]string, error) {
+	args := make(map[string]string)
+	decoder := xml.NewDecoder(body)
+	depth := 0
+	var name string
+	var value strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return args, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		// Envelope > Body > Action > Argument
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 4 {
+				name = t.Name.Local
+				value.Reset()
+			}
+		case xml.CharData:
+			if depth == 4 {
+				value.Write(t)
+			}
+		case xml.EndElement:
+			if depth == 4 {
+				args[name] = value.String()
+			}
+			depth--
+		}
+	}
+}
+
+func writeSOAPFault(w http.ResponseWriter, err error) {
+	e, ok := err.(*upnpError)
+	if !ok {
+		e = &upnpError{501, "Action Failed"}
+	}
+	writeSOAPEnvelope(w, http.StatusInternalServerError, fmt.Sprintf(
+		`<s:Fault><faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>`+
+			`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>%d</errorCode>`+
+			`<errorDescription>%s</errorDescription></UPnPError></detail></s:Fault>`, e.Code, e.Description))
+}
+
+func writeSOAPEnvelope(w http.ResponseWriter, statusCode int, body string) {
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	w.Header().Set("EXT", "")
+	w.WriteHeader(statusCode)
+	if _, err := io.WriteString(w, `<?xml version="1.0" encoding="utf-8"?>`+
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" `+
+		`s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`+
+		body+`</s:Body></s:Envelope>`); err != nil {
+		log.Errorf("Failed writing SOAP response: %s", err)
+	}
+}
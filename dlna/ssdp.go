@@ -0,0 +1,233 @@
+package dlna
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/i96751414/torrest/util"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	ssdpMaxAge         = 1800
+	ssdpNotifyInterval = 300 * time.Second
+	ssdpMaxDelay       = 3 * time.Second
+	ssdpBufferSize     = 2048
+)
+
+var ssdpGroupAddr = &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: 1900}
+
+// ssdpResponder answers to M-SEARCH discovery requests and periodically
+// advertises the media server on all the multicast capable interfaces
+type ssdpResponder struct {
+	uuid        string
+	httpPort    int
+	server      string
+	conn        *net.UDPConn
+	closing     chan interface{}
+	notifyTypes []string
+}
+
+func newSSDPResponder(uuid string, httpPort int) *ssdpResponder {
+	return &ssdpResponder{
+		uuid:     uuid,
+		httpPort: httpPort,
+		server:   fmt.Sprintf("%s/%s UPnP/1.0 torrest/%s", runtime.GOOS, runtime.Version(), util.GetVersion()),
+		closing:  make(chan interface{}),
+		notifyTypes: []string{
+			"upnp:rootdevice",
+			"uuid:" + uuid,
+			mediaServerDeviceType,
+			contentDirectoryServiceType,
+			connectionManagerServiceType,
+		},
+	}
+}
+
+func (s *ssdpResponder) start() (err error) {
+	if s.conn, err = net.ListenMulticastUDP("udp4", nil, ssdpGroupAddr); err != nil {
+		return err
+	}
+
+	packetConn := ipv4.NewPacketConn(s.conn)
+	for _, iface := range multicastInterfaces() {
+		i := iface
+		if e := packetConn.JoinGroup(&i, ssdpGroupAddr); e != nil {
+			log.Debugf("Unable to join SSDP group on %s: %s", iface.Name, e)
+		}
+	}
+
+	go s.serve()
+	go s.notifyLoop()
+	return nil
+}
+
+func (s *ssdpResponder) close() {
+	close(s.closing)
+	s.notify("ssdp:byebye")
+	if err := s.conn.Close(); err != nil {
+		log.Errorf("Failed closing SSDP connection: %s", err)
+	}
+}
+
+func (s *ssdpResponder) serve() {
+	buf := make([]byte, ssdpBufferSize)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-s.closing:
+				return
+			default:
+				log.Errorf("Failed reading SSDP packet: %s", err)
+				continue
+			}
+		}
+
+		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(buf[:n])))
+		if err != nil || req.Method != "M-SEARCH" || req.Header.Get("Man") != `"ssdp:discover"` {
+			continue
+		}
+
+		if targets := s.searchTargets(req.Header.Get("St")); len(targets) > 0 {
+			maxDelay := ssdpMaxDelay
+			if mx, e := strconv.Atoi(req.Header.Get("Mx")); e == nil && mx >= 0 && time.Duration(mx)*time.Second < maxDelay {
+				maxDelay = time.Duration(mx) * time.Second
+			}
+			go s.respond(addr, targets, time.Duration(rand.Int63n(int64(maxDelay)+1)))
+		}
+	}
+}
+
+func (s *ssdpResponder) searchTargets(st string) []string {
+	if st == "ssdp:all" {
+		return s.notifyTypes
+	}
+	for _, nt := range s.notifyTypes {
+		if nt == st {
+			return []string{st}
+		}
+	}
+	return nil
+}
+
+func (s *ssdpResponder) respond(addr *net.UDPAddr, targets []string, delay time.Duration) {
+	select {
+	case <-s.closing:
+		return
+	case <-time.After(delay):
+	}
+
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		log.Debugf("Unable to reply to SSDP search from %s: %s", addr, err)
+		return
+	}
+	//noinspection GoUnhandledErrorResult
+	defer conn.Close()
+
+	localIP := conn.LocalAddr().(*net.UDPAddr).IP
+	for _, st := range targets {
+		response := "HTTP/1.1 200 OK\r\n" +
+			"CACHE-CONTROL: max-age=" + strconv.Itoa(ssdpMaxAge) + "\r\n" +
+			"DATE: " + time.Now().UTC().Format(http.TimeFormat) + "\r\n" +
+			"EXT:\r\n" +
+			"LOCATION: " + s.location(localIP) + "\r\n" +
+			"SERVER: " + s.server + "\r\n" +
+			"ST: " + st + "\r\n" +
+			"USN: " + s.usn(st) + "\r\n" +
+			"\r\n"
+		if _, err := conn.Write([]byte(response)); err != nil {
+			log.Debugf("Failed replying to SSDP search from %s: %s", addr, err)
+		}
+	}
+}
+
+func (s *ssdpResponder) notifyLoop() {
+	ticker := time.NewTicker(ssdpNotifyInterval)
+	defer ticker.Stop()
+
+	s.notify("ssdp:alive")
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			s.notify("ssdp:alive")
+		}
+	}
+}
+
+func (s *ssdpResponder) notify(nts string) {
+	for _, ip := range multicastIPs() {
+		conn, err := net.DialUDP("udp4", &net.UDPAddr{IP: ip}, ssdpGroupAddr)
+		if err != nil {
+			log.Debugf("Unable to send SSDP notify from %s: %s", ip, err)
+			continue
+		}
+
+		for _, nt := range s.notifyTypes {
+			message := "NOTIFY * HTTP/1.1\r\n" +
+				"HOST: " + ssdpGroupAddr.String() + "\r\n" +
+				"NT: " + nt + "\r\n" +
+				"NTS: " + nts + "\r\n" +
+				"USN: " + s.usn(nt) + "\r\n"
+			if nts == "ssdp:alive" {
+				message += "CACHE-CONTROL: max-age=" + strconv.Itoa(ssdpMaxAge) + "\r\n" +
+					"LOCATION: " + s.location(ip) + "\r\n" +
+					"SERVER: " + s.server + "\r\n"
+			}
+			if _, err := conn.Write([]byte(message + "\r\n")); err != nil {
+				log.Debugf("Failed sending SSDP notify from %s: %s", ip, err)
+			}
+		}
+
+		//noinspection GoUnhandledErrorResult
+		conn.Close()
+	}
+}
+
+func (s *ssdpResponder) location(ip net.IP) string {
+	return "http://" + net.JoinHostPort(ip.String(), strconv.Itoa(s.httpPort)) + deviceDescriptionPath
+}
+
+func (s *ssdpResponder) usn(nt string) string {
+	if strings.HasPrefix(nt, "uuid:") {
+		return nt
+	}
+	return "uuid:" + s.uuid + "::" + nt
+}
+
+func multicastInterfaces() []net.Interface {
+	var result []net.Interface
+	if interfaces, err := net.Interfaces(); err == nil {
+		for _, iface := range interfaces {
+			if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagMulticast != 0 && iface.Flags&net.FlagLoopback == 0 {
+				result = append(result, iface)
+			}
+		}
+	}
+	return result
+}
+
+func multicastIPs() []net.IP {
+	var result []net.IP
+	for _, iface := range multicastInterfaces() {
+		if addresses, err := iface.Addrs(); err == nil {
+			for _, address := range addresses {
+				if ipNet, ok := address.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+					result = append(result, ipNet.IP.To4())
+				}
+			}
+		}
+	}
+	return result
+}
@@ -59,25 +59,48 @@ var doc = `{
                         "description": "start downloading",
                         "name": "download",
                         "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "http(s) web seed url, can be repeated (ftp is not supported)",
+                        "name": "web_seed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "none",
+                        "description": "file to download once the metadata is received (largest_video, largest, all or none), ignored if the torrent was already added",
+                        "name": "auto_select",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "buffer the auto selected file",
+                        "name": "buffer",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.NewTorrentResponse"
+                            "$ref": "#/definitions/models.NewTorrentResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
@@ -113,25 +136,161 @@ var doc = `{
                         "description": "start downloading",
                         "name": "download",
                         "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "http(s) web seed url, can be repeated (ftp is not supported)",
+                        "name": "web_seed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "none",
+                        "description": "file to download (largest_video, largest, all or none), ignored if the torrent was already added",
+                        "name": "auto_select",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "buffer the auto selected file",
+                        "name": "buffer",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.NewTorrentResponse"
+                            "$ref": "#/definitions/models.NewTorrentResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ban": {
+            "get": {
+                "description": "ban peer ip address from all torrents",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Ban Peer",
+                "operationId": "ban-peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "peer ip address",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/bans": {
+            "get": {
+                "description": "get all banned peers, both global and per torrent",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Bans",
+                "operationId": "bans",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/bittorrent.Bans"
+                        }
+                    }
+                }
+            }
+        },
+        "/blocklist/reload": {
+            "get": {
+                "description": "reload the blocklist sources in background",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Reload Blocklist",
+                "operationId": "blocklist-reload",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/blocklist/status": {
+            "get": {
+                "description": "get the number of rules and the result of the last blocklist load",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Blocklist Status",
+                "operationId": "blocklist-status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/bittorrent.BlocklistStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/fsck": {
+            "get": {
+                "description": "check the consistency of the torrents and download paths",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Check Files",
+                "operationId": "fsck",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "remove the problematic files, except for unreferenced data and the files of the loaded torrents, which are only reported",
+                        "name": "fix",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/bittorrent.FsckReport"
                         }
                     }
                 }
@@ -149,7 +308,65 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/resolve": {
+            "get": {
+                "description": "add a magnet without downloading, or reuse the existing torrent, and wait for its metadata, returning the list of files",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Resolve Magnet",
+                "operationId": "resolve",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "magnet URI",
+                        "name": "uri",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 60,
+                        "description": "maximum time to wait for the metadata, in seconds (up to 300)",
+                        "name": "timeout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "keep the torrent after resolving it. Otherwise it is removed, unless added again by another request while resolving",
+                        "name": "keep",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ResolveResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
@@ -167,7 +384,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
+                            "$ref": "#/definitions/models.MessageResponse"
                         }
                     }
                 }
@@ -191,6 +408,24 @@ var doc = `{
                 }
             }
         },
+        "/settings/libtorrent": {
+            "get": {
+                "description": "get the effective value of all libtorrent settings",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get libtorrent settings",
+                "operationId": "get-libtorrent-settings",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
         "/settings/set": {
             "post": {
                 "description": "set settings given the provided JSON object",
@@ -225,10 +460,34 @@ var doc = `{
                             "$ref": "#/definitions/settings.Settings"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/settings/sources": {
+            "get": {
+                "description": "get where each effective setting value came from (default, file, env or api)",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get settings sources",
+                "operationId": "get-settings-sources",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
                         }
                     }
                 }
@@ -258,7 +517,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/bittorrent.ServiceStatus"
+                            "$ref": "#/definitions/models.ServiceStatus"
                         }
                     }
                 }
@@ -286,21 +545,21 @@ var doc = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/api.TorrentInfoResponse"
+                                "$ref": "#/definitions/models.TorrentInfoResponse"
                             }
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/download": {
+        "/torrents/{infoHash}/add_url_seed": {
             "get": {
-                "description": "download all files from torrent",
+                "description": "add a http(s) web seed (BEP 19) to the torrent. FTP web seeds are not supported by libtorrent and are rejected.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Download",
-                "operationId": "download-torrent",
+                "summary": "Add Url Seed",
+                "operationId": "add-url-seed",
                 "parameters": [
                     {
                         "type": "string",
@@ -308,38 +567,46 @@ var doc = `{
                         "name": "infoHash",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "http(s) web seed url",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
+                            "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/files": {
+        "/torrents/{infoHash}/archive": {
             "get": {
-                "description": "get a list of the torrent files and its details",
+                "description": "download all torrent files, or the ones inside a directory, as a single uncompressed archive",
                 "produces": [
-                    "application/json"
+                    "application/zip",
+                    "application/x-tar"
                 ],
-                "summary": "Get Torrent Files",
-                "operationId": "torrent-files",
+                "summary": "Download Archive",
+                "operationId": "archive-torrent",
                 "parameters": [
                     {
                         "type": "string",
@@ -349,45 +616,49 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "type": "boolean",
-                        "description": "get files status",
-                        "name": "status",
+                        "type": "string",
+                        "description": "archive format (zip or tar)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "directory to archive, as in the files paths",
+                        "name": "path",
                         "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "200": {},
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/api.FileInfoResponse"
-                            }
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/files/{file}/download": {
+        "/torrents/{infoHash}/ban": {
             "get": {
-                "description": "download file from torrent given its id",
+                "description": "ban peer ip address from torrent. Libtorrent only supports a session wide ip filter, so the peer is blocked from all torrents while the ban exists. The ban is discarded once the torrent is removed.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Download File",
-                "operationId": "download-file",
+                "summary": "Ban Torrent Peer",
+                "operationId": "ban-torrent-peer",
                 "parameters": [
                     {
                         "type": "string",
@@ -397,49 +668,43 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "file id",
-                        "name": "file",
-                        "in": "path",
+                        "type": "string",
+                        "description": "peer ip address",
+                        "name": "ip",
+                        "in": "query",
                         "required": true
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "buffer file",
-                        "name": "buffer",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
+                            "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/files/{file}/hash": {
+        "/torrents/{infoHash}/bans": {
             "get": {
-                "description": "calculate file hash suitable for opensubtitles",
+                "description": "get the peers banned from torrent",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Calculate file hash",
-                "operationId": "file-hash",
+                "summary": "Get Torrent Bans",
+                "operationId": "torrent-bans",
                 "parameters": [
                     {
                         "type": "string",
@@ -447,51 +712,36 @@ var doc = `{
                         "name": "infoHash",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "file id",
-                        "name": "file",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.FileHash"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/files/{file}/info": {
+        "/torrents/{infoHash}/browse/{path}": {
             "get": {
-                "description": "get file info from torrent given its id",
+                "description": "serve a file given its path or list the contents of a directory, either in html or json",
                 "produces": [
+                    "text/html",
                     "application/json"
                 ],
-                "summary": "Get File Info",
-                "operationId": "file-info",
+                "summary": "Browse Torrent",
+                "operationId": "browse-torrent",
                 "parameters": [
                     {
                         "type": "string",
@@ -501,43 +751,58 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "file id",
-                        "name": "file",
+                        "type": "string",
+                        "description": "file or directory path",
+                        "name": "path",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "content disposition of served files (inline or attachment)",
+                        "name": "disposition",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/bittorrent.FileInfo"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.DirectoryEntry"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/files/{file}/serve": {
+        "/torrents/{infoHash}/connect": {
             "get": {
-                "description": "serve file from torrent given its id",
+                "description": "connect torrent to a specific peer",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Serve File",
-                "operationId": "serve-file",
+                "summary": "Connect Peer",
+                "operationId": "connect-peer",
                 "parameters": [
                     {
                         "type": "string",
@@ -547,38 +812,43 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "file id",
-                        "name": "file",
-                        "in": "path",
+                        "type": "string",
+                        "description": "peer address, in the host:port format",
+                        "name": "address",
+                        "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {},
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/files/{file}/status": {
+        "/torrents/{infoHash}/download": {
             "get": {
-                "description": "get file status from torrent given its id",
+                "description": "download all files from torrent",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get File Status",
-                "operationId": "file-status",
+                "summary": "Download",
+                "operationId": "download-torrent",
                 "parameters": [
                     {
                         "type": "string",
@@ -588,43 +858,97 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "file id",
-                        "name": "file",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "default": "default",
+                        "description": "download priority, either low, default, high, top or a value between 1 and 7",
+                        "name": "priority",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/bittorrent.FileStatus"
+                            "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/files/{file}/stop": {
+        "/torrents/{infoHash}/files": {
             "get": {
-                "description": "stop file download from torrent given its id",
+                "description": "get a list of the torrent files and its details",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Stop File Download",
-                "operationId": "stop-file",
+                "summary": "Get Torrent Files",
+                "operationId": "torrent-files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "get files status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FileInfoResponse"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/files/{file}/buffer": {
+            "get": {
+                "description": "buffer a range of the file from torrent given its id, e.g. before seeking",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Buffer File Range",
+                "operationId": "buffer-file",
                 "parameters": [
                     {
                         "type": "string",
@@ -639,38 +963,51 @@ var doc = `{
                         "name": "file",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "range start offset",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "range length (defaults to the configured buffer size)",
+                        "name": "length",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
+                            "$ref": "#/definitions/models.MessageResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/info": {
+        "/torrents/{infoHash}/files/{file}/download": {
             "get": {
-                "description": "get torrent info",
+                "description": "download file from torrent given its id",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get Torrent Info",
-                "operationId": "torrent-info",
+                "summary": "Download File",
+                "operationId": "download-file",
                 "parameters": [
                     {
                         "type": "string",
@@ -678,32 +1015,70 @@ var doc = `{
                         "name": "infoHash",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "file id",
+                        "name": "file",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "buffer file",
+                        "name": "buffer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "buffering mode (default or container)",
+                        "name": "buffer_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "media duration hint in seconds, used if not available from the container",
+                        "name": "duration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "default",
+                        "description": "download priority, either low, default, high, top or a value between 1 and 7",
+                        "name": "priority",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/bittorrent.TorrentInfo"
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/pause": {
+        "/torrents/{infoHash}/files/{file}/hash": {
             "get": {
-                "description": "pause torrent from service",
+                "description": "calculate file hash suitable for opensubtitles",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Pause Torrent",
-                "operationId": "pause-torrent",
+                "summary": "Calculate file hash",
+                "operationId": "file-hash",
                 "parameters": [
                     {
                         "type": "string",
@@ -711,32 +1086,51 @@ var doc = `{
                         "name": "infoHash",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "file id",
+                        "name": "file",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
+                            "$ref": "#/definitions/api.FileHash"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/remove": {
+        "/torrents/{infoHash}/files/{file}/info": {
             "get": {
-                "description": "remove torrent from service",
+                "description": "get file info from torrent given its id",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Remove Torrent",
-                "operationId": "remove-torrent",
+                "summary": "Get File Info",
+                "operationId": "file-info",
                 "parameters": [
                     {
                         "type": "string",
@@ -746,36 +1140,43 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "type": "boolean",
-                        "description": "delete files",
-                        "name": "delete",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "file id",
+                        "name": "file",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
+                            "$ref": "#/definitions/models.FileInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/resume": {
+        "/torrents/{infoHash}/files/{file}/serve": {
             "get": {
-                "description": "resume a paused torrent",
+                "description": "serve file from torrent given its id",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Resume Torrent",
-                "operationId": "resume-torrent",
+                "summary": "Serve File",
+                "operationId": "serve-file",
                 "parameters": [
                     {
                         "type": "string",
@@ -783,32 +1184,46 @@ var doc = `{
                         "name": "infoHash",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "file id",
+                        "name": "file",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "content disposition (inline or attachment)",
+                        "name": "disposition",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "200": {},
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/status": {
+        "/torrents/{infoHash}/files/{file}/status": {
             "get": {
-                "description": "get torrent status",
+                "description": "get file status from torrent given its id",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get Torrent Status",
-                "operationId": "torrent-status",
+                "summary": "Get File Status",
+                "operationId": "file-status",
                 "parameters": [
                     {
                         "type": "string",
@@ -816,140 +1231,1098 @@ var doc = `{
                         "name": "infoHash",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "file id",
+                        "name": "file",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "media duration hint in seconds, used if not available from the container",
+                        "name": "duration",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/bittorrent.TorrentStatus"
+                            "$ref": "#/definitions/models.FileStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/torrents/{infoHash}/stop": {
-            "get": {
-                "description": "stop downloading torrent",
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "Stop Download",
-                "operationId": "stop-torrent",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "torrent info hash",
-                        "name": "infoHash",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/api.MessageResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
-                        }
+        "/torrents/{infoHash}/files/{file}/stop": {
+            "get": {
+                "description": "stop file download from torrent given its id",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Stop File Download",
+                "operationId": "stop-file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "file id",
+                        "name": "file",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/files/{file}/wait/buffer": {
+            "get": {
+                "description": "wait until the file buffering finishes, returning the file status",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Wait For Buffering",
+                "operationId": "wait-buffer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "file id",
+                        "name": "file",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "maximum time to wait, in seconds (up to 300)",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WaitFileResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/files/{file}/wait/complete": {
+            "get": {
+                "description": "wait until the file is completely downloaded, returning the file status",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Wait For Completion",
+                "operationId": "wait-complete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "file id",
+                        "name": "file",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "maximum time to wait, in seconds (up to 300)",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WaitFileResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/info": {
+            "get": {
+                "description": "get torrent info",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Torrent Info",
+                "operationId": "torrent-info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TorrentInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/magnet": {
+            "get": {
+                "description": "generate the torrent magnet uri",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Torrent Magnet",
+                "operationId": "torrent-magnet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "include trackers in the magnet uri",
+                        "name": "trackers",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MagnetResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/metadata": {
+            "get": {
+                "description": "get detailed torrent metadata, such as comment, creator, pieces, trackers and web seeds",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Torrent Metadata",
+                "operationId": "torrent-metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TorrentMetadata"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/pause": {
+            "get": {
+                "description": "pause torrent from service",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Pause Torrent",
+                "operationId": "pause-torrent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/peers": {
+            "get": {
+                "description": "get the torrent peer list and the status of each peer. Only web seeds are listed, as libtorrent-go does not expose the swarm peers. Libtorrent only reports web seed errors, so failed is set while the last error is less than 2 minutes old, after which the web seed is assumed to have recovered, as libtorrent retries it. The last error is always reported.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Torrent Peers",
+                "operationId": "torrent-peers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/bittorrent.PeerInfo"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/remove": {
+            "get": {
+                "description": "remove torrent from service",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Remove Torrent",
+                "operationId": "remove-torrent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "delete files",
+                        "name": "delete",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/remove_url_seed": {
+            "get": {
+                "description": "remove a web seed from the torrent",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Remove Url Seed",
+                "operationId": "remove-url-seed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "web seed url",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/resume": {
+            "get": {
+                "description": "resume a paused torrent",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Resume Torrent",
+                "operationId": "resume-torrent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/select": {
+            "get": {
+                "description": "set the priority of the torrent files matching all the provided criteria, e.g. glob=*.mkv\u0026min_size=100MB\u0026priority=high",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Select Files",
+                "operationId": "select-files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "glob pattern matched against the file name, or the file path if it contains a separator",
+                        "name": "glob",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "regular expression matched against the file path",
+                        "name": "regex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated list of file extensions",
+                        "name": "ext",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "minimum file size, e.g. 100MB",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "maximum file size, e.g. 2GB",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "default",
+                        "description": "priority, either dont_download, low, default, high, top or a value between 0 and 7",
+                        "name": "priority",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "do not download the files not matching the criteria",
+                        "name": "exclusive",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.SelectFilesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/status": {
+            "get": {
+                "description": "get torrent status",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Torrent Status",
+                "operationId": "torrent-status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TorrentStatus"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/stop": {
+            "get": {
+                "description": "stop downloading torrent",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Stop Download",
+                "operationId": "stop-torrent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/torrent": {
+            "get": {
+                "description": "download the torrent file, which is only available after receiving the torrent metadata",
+                "produces": [
+                    "application/x-bittorrent"
+                ],
+                "summary": "Get Torrent File",
+                "operationId": "torrent-file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/unban": {
+            "get": {
+                "description": "remove torrent ban of peer ip address",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Unban Torrent Peer",
+                "operationId": "unban-torrent-peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "peer ip address",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/wait/metadata": {
+            "get": {
+                "description": "wait until the torrent metadata is received, returning the torrent status",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Wait For Metadata",
+                "operationId": "wait-metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "maximum time to wait, in seconds (up to 300)",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WaitTorrentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/torrents/{infoHash}/wait/state": {
+            "get": {
+                "description": "wait until the torrent state changes, returning the torrent status",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Wait For State Change",
+                "operationId": "wait-state",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "torrent info hash",
+                        "name": "infoHash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "state to change from, either its name or value (defaults to the current state)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "maximum time to wait, in seconds (up to 300)",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.WaitTorrentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/unban": {
+            "get": {
+                "description": "remove global ban of peer ip address",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Unban Peer",
+                "operationId": "unban-peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "peer ip address",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.DirectoryEntry": {
+            "type": "object",
+            "properties": {
+                "file_id": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "is_dir": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "length": {
+                    "type": "integer",
+                    "example": 1073741824
+                },
+                "name": {
+                    "type": "string",
+                    "example": "video.mkv"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "folder/video.mkv"
+                }
+            }
+        },
+        "api.FileHash": {
+            "type": "object",
+            "properties": {
+                "hash": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ResolveResponse": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FileInfo"
+                    }
+                },
+                "info_hash": {
+                    "type": "string"
+                },
+                "kept": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "type": "string"
+                },
+                "selected_file": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.SelectFilesResponse": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
-                        }
-                    }
+                    "example": [
+                        0,
+                        2
+                    ]
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.ErrorResponse": {
+        },
+        "api.WaitFileResponse": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "Houston, we have a problem!"
+                "done": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "status": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.FileStatus"
                 }
             }
         },
-        "api.FileHash": {
+        "api.WaitTorrentResponse": {
             "type": "object",
             "properties": {
-                "hash": {
-                    "type": "string"
+                "done": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "status": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.TorrentStatus"
                 }
             }
         },
-        "api.FileInfoResponse": {
+        "bittorrent.Bans": {
             "type": "object",
             "properties": {
-                "id": {
-                    "type": "integer"
+                "global": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "length": {
-                    "type": "integer"
+                "torrents": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "bittorrent.BlocklistStatus": {
+            "type": "object",
+            "properties": {
+                "blocked_peers": {
+                    "type": "integer",
+                    "example": 10
                 },
-                "name": {
+                "invalid_lines": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "last_error": {
                     "type": "string"
                 },
-                "path": {
+                "last_load": {
                     "type": "string"
                 },
-                "status": {
-                    "type": "object",
-                    "$ref": "#/definitions/bittorrent.FileStatus"
+                "rules": {
+                    "type": "integer",
+                    "example": 250000
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "api.MessageResponse": {
+        "bittorrent.FsckIssue": {
             "type": "object",
             "properties": {
-                "message": {
+                "error": {
+                    "type": "string"
+                },
+                "fixed": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "path": {
                     "type": "string",
-                    "example": "done"
+                    "example": "downloads/.000102030405060708090a0b0c0d0e0f10111213.parts"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "stale_parts"
                 }
             }
         },
-        "api.NewTorrentResponse": {
+        "bittorrent.FsckReport": {
             "type": "object",
             "properties": {
-                "info_hash": {
-                    "type": "string",
-                    "example": "000102030405060708090a0b0c0d0e0f10111213"
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/bittorrent.FsckIssue"
+                    }
                 }
             }
         },
-        "api.TorrentInfoResponse": {
+        "bittorrent.PeerInfo": {
             "type": "object",
             "properties": {
-                "info_hash": {
-                    "type": "string"
+                "address": {
+                    "type": "string",
+                    "example": "https://mirror.example.com/files/"
                 },
-                "name": {
+                "error": {
                     "type": "string"
                 },
-                "size": {
+                "error_time": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "failed": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "type": {
+                    "type": "string",
+                    "example": "web_seed"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Houston, we have a problem!"
+                }
+            }
+        },
+        "models.FileInfo": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "integer"
                 },
-                "status": {
-                    "type": "object",
-                    "$ref": "#/definitions/bittorrent.TorrentStatus"
+                "length": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
                 }
             }
         },
-        "bittorrent.FileInfo": {
+        "models.FileInfoResponse": {
             "type": "object",
             "properties": {
                 "id": {
@@ -963,12 +2336,22 @@ var doc = `{
                 },
                 "path": {
                     "type": "string"
+                },
+                "status": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.FileStatus"
                 }
             }
         },
-        "bittorrent.FileStatus": {
+        "models.FileStatus": {
             "type": "object",
             "properties": {
+                "bitrate": {
+                    "type": "integer"
+                },
+                "buffering_eta": {
+                    "type": "integer"
+                },
                 "buffering_progress": {
                     "type": "number"
                 },
@@ -981,6 +2364,9 @@ var doc = `{
                 "progress": {
                     "type": "number"
                 },
+                "safe_to_start": {
+                    "type": "boolean"
+                },
                 "state": {
                     "type": "integer"
                 },
@@ -992,12 +2378,45 @@ var doc = `{
                 }
             }
         },
-        "bittorrent.ServiceStatus": {
+        "models.MagnetResponse": {
+            "type": "object",
+            "properties": {
+                "magnet": {
+                    "type": "string",
+                    "example": "magnet:?xt=urn:btih:000102030405060708090a0b0c0d0e0f10111213"
+                }
+            }
+        },
+        "models.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "done"
+                }
+            }
+        },
+        "models.NewTorrentResponse": {
+            "type": "object",
+            "properties": {
+                "info_hash": {
+                    "type": "string",
+                    "example": "000102030405060708090a0b0c0d0e0f10111213"
+                }
+            }
+        },
+        "models.ServiceStatus": {
             "type": "object",
             "properties": {
+                "blocked_peers": {
+                    "type": "integer"
+                },
                 "download_rate": {
                     "type": "integer"
                 },
+                "downloaded": {
+                    "type": "integer"
+                },
                 "is_paused": {
                     "type": "boolean"
                 },
@@ -1009,10 +2428,30 @@ var doc = `{
                 },
                 "upload_rate": {
                     "type": "integer"
+                },
+                "uploaded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TorrentInfo": {
+            "type": "object",
+            "properties": {
+                "info_hash": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "selected_file": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
                 }
             }
         },
-        "bittorrent.TorrentInfo": {
+        "models.TorrentInfoResponse": {
             "type": "object",
             "properties": {
                 "info_hash": {
@@ -1021,12 +2460,86 @@ var doc = `{
                 "name": {
                     "type": "string"
                 },
+                "selected_file": {
+                    "type": "integer"
+                },
                 "size": {
                     "type": "integer"
+                },
+                "status": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.TorrentStatus"
+                }
+            }
+        },
+        "models.TorrentMetadata": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "example": "Big Buck Bunny, Blender Foundation"
+                },
+                "creation_date": {
+                    "type": "integer",
+                    "example": 1589289328
+                },
+                "creator": {
+                    "type": "string",
+                    "example": "mktorrent 1.1"
+                },
+                "has_metadata": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "info_hash": {
+                    "type": "string",
+                    "example": "000102030405060708090a0b0c0d0e0f10111213"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Big Buck Bunny"
+                },
+                "num_files": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "num_pieces": {
+                    "type": "integer",
+                    "example": 1054
+                },
+                "piece_length": {
+                    "type": "integer",
+                    "example": 262144
+                },
+                "private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 276134947
+                },
+                "trackers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "udp://tracker.opentrackr.org:1337/announce"
+                    ]
+                },
+                "web_seeds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://webtorrent.io/torrents/"
+                    ]
                 }
             }
         },
-        "bittorrent.TorrentStatus": {
+        "models.TorrentStatus": {
             "type": "object",
             "properties": {
                 "active_time": {
@@ -1088,6 +2601,31 @@ var doc = `{
                 }
             }
         },
+        "settings.PeerFingerprint": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string",
+                    "example": "LT"
+                },
+                "major": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "minor": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "revision": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "tag": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
         "settings.ProxySettings": {
             "type": "object",
             "properties": {
@@ -1151,6 +2689,19 @@ var doc = `{
                     "type": "integer",
                     "example": 1
                 },
+                "blocklist_refresh": {
+                    "type": "integer",
+                    "example": 86400
+                },
+                "blocklist_sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://example.com/blocklist.p2p.gz"
+                    ]
+                },
                 "buffer_size": {
                     "type": "integer",
                     "example": 20971520
@@ -1163,6 +2714,18 @@ var doc = `{
                     "type": "integer",
                     "example": 200
                 },
+                "custom_user_agent": {
+                    "type": "string"
+                },
+                "dht_bootstrap_nodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "router.bittorrent.com:6881"
+                    ]
+                },
                 "disable_dht": {
                     "type": "boolean",
                     "example": false
@@ -1187,6 +2750,9 @@ var doc = `{
                     "type": "integer",
                     "example": 0
                 },
+                "libtorrent_overrides": {
+                    "type": "object"
+                },
                 "limit_after_buffering": {
                     "type": "boolean",
                     "example": false
@@ -1209,6 +2775,10 @@ var doc = `{
                 "outgoing_interfaces": {
                     "type": "string"
                 },
+                "peer_fingerprint": {
+                    "type": "object",
+                    "$ref": "#/definitions/settings.PeerFingerprint"
+                },
                 "piece_wait_timeout": {
                     "type": "integer",
                     "example": 60
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/i96751414/torrest/bittorrent"
+	"github.com/i96751414/torrest/settings"
+	"github.com/op/go-logging"
+)
+
+// fsckCommand checks the consistency of the torrents and download paths. It
+// must be run while the daemon is stopped, otherwise use the /fsck endpoint.
+// Returns 1 if there are issues left to fix. Unreferenced data is never fixed,
+// so it does not affect the exit status.
+func fsckCommand(args []string) int {
+	var settingsPath string
+	var fix, jsonOutput bool
+	flags := flag.NewFlagSet("fsck", flag.ExitOnError)
+	flags.StringVar(&settingsPath, "settings", "settings.json", "Settings path (JSON, YAML or TOML)")
+	flags.BoolVar(&fix, "fix", false, "Remove the problematic files, except for unreferenced data")
+	flags.BoolVar(&jsonOutput, "json", false, "Print the report in JSON format")
+	_ = flags.Parse(args)
+
+	// The report already contains the issues
+	logging.SetLevel(logging.ERROR, "bittorrent")
+
+	config, err := settings.Load(settingsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed loading settings: %s\n", err)
+		return 2
+	}
+
+	report := bittorrent.Fsck(config, fix)
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	} else {
+		for _, issue := range report.Issues {
+			status := ""
+			if issue.Fixed {
+				status = " (fixed)"
+			} else if issue.Error != "" {
+				status = " (" + issue.Error + ")"
+			}
+			fmt.Printf("%-17s %s%s\n", issue.Type, issue.Path, status)
+		}
+		fmt.Printf("%d issues found\n", len(report.Issues))
+	}
+
+	for _, issue := range report.Issues {
+		if issue.IsFixable() && !issue.Fixed {
+			return 1
+		}
+	}
+	return 0
+}
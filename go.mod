@@ -3,6 +3,7 @@ module github.com/i96751414/torrest
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751
 	github.com/dustin/go-humanize v1.0.0
 	github.com/gin-gonic/gin v1.7.4
@@ -17,5 +18,7 @@ require (
 	github.com/swaggo/swag v1.7.4
 	github.com/ugorji/go v1.1.13 // indirect
 	github.com/zeebo/bencode v1.0.0
+	golang.org/x/net v0.0.0-20210805182204-aaa1db679c0d
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
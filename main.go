@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/i96751414/torrest/api"
 	"github.com/i96751414/torrest/bittorrent"
+	"github.com/i96751414/torrest/dlna"
 	"github.com/i96751414/torrest/settings"
 	"github.com/op/go-logging"
 )
@@ -19,12 +21,21 @@ import (
 var log = logging.MustGetLogger("main")
 
 func main() {
+	if exitCode, ok := runCommand(os.Args[1:]); ok {
+		os.Exit(exitCode)
+	}
+
 	// Parse necessary arguments
 	var listenPort int
-	var settingsPath, origin string
-	flag.IntVar(&listenPort, "port", 8080, "Server listen port")
-	flag.StringVar(&settingsPath, "settings", "settings.json", "Settings path")
+	var settingsPath, origin, unixSocket, unixSocketMode string
+	var enableDLNA bool
+	flag.IntVar(&listenPort, "port", 8080, "Server listen port (0 to disable the TCP listener)")
+	flag.StringVar(&unixSocket, "unix-socket", "", "Also listen on the provided Unix domain socket path")
+	flag.StringVar(&unixSocketMode, "unix-socket-mode", "0660", "Unix domain socket file permissions, in octal")
+	flag.StringVar(&settingsPath, "settings", "settings.json", "Settings path (JSON, YAML or TOML)")
 	flag.StringVar(&origin, "origin", "*", "Access-Control-Allow-Origin header value")
+	flag.BoolVar(&enableDLNA, "dlna", false, "Enable DLNA/UPnP media server")
+	flag.Usage = commandsUsage
 	flag.Parse()
 
 	// Make sure we are properly multi threaded.
@@ -36,10 +47,7 @@ func main() {
 	logging.SetBackend(logging.NewLogBackend(os.Stdout, "", 0))
 
 	m := http.NewServeMux()
-	s := http.Server{
-		Addr:    ":" + strconv.Itoa(listenPort),
-		Handler: m,
-	}
+	s := http.Server{Handler: m}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -57,27 +65,92 @@ func main() {
 	m.Handle("/", api.Routes(config, service, origin))
 	m.HandleFunc("/shutdown", shutdown(cancel, origin))
 
-	log.Infof("Starting torrent daemon on port %d", listenPort)
-	go func() {
-		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	// Sockets passed by systemd replace the TCP listener
+	listeners, err := systemdListeners()
+	if err != nil {
+		log.Fatalf("Failed using systemd sockets: %s", err)
+	}
+	if len(listeners) > 0 {
+		log.Infof("Using %d socket(s) passed by systemd", len(listeners))
+	} else if listenPort > 0 {
+		listener, err := net.Listen("tcp", ":"+strconv.Itoa(listenPort))
+		if err != nil {
 			log.Fatal(err)
 		}
-	}()
+		log.Infof("Starting torrent daemon on port %d", listenPort)
+		listeners = append(listeners, listener)
+	}
+	if unixSocket != "" {
+		mode, err := strconv.ParseUint(unixSocketMode, 8, 32)
+		if err != nil {
+			log.Fatalf("Invalid unix socket mode '%s'", unixSocketMode)
+		}
+		listener, err := unixListener(unixSocket, os.FileMode(mode))
+		if err != nil {
+			log.Fatalf("Failed listening on unix socket: %s", err)
+		}
+		log.Infof("Starting torrent daemon on unix socket %s", unixSocket)
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		log.Fatal("No listeners available, please provide a port or a unix socket")
+	}
+
+	if enableDLNA {
+		// Renderers are pointed to the port of the actual TCP listener, which
+		// may have been passed by systemd
+		if port := tcpListenerPort(listeners); port == 0 {
+			log.Warning("DLNA media server requires a TCP listener")
+		} else {
+			mediaServer := dlna.NewMediaServer(service, port)
+			m.Handle(dlna.PathPrefix, mediaServer)
+			if err := mediaServer.Start(); err == nil {
+				defer mediaServer.Close()
+			} else {
+				log.Errorf("Failed starting DLNA media server: %s", err)
+			}
+		}
+	}
+
+	for _, listener := range listeners {
+		go func(l net.Listener) {
+			if err := s.Serve(l); err != nil && err != http.ErrServerClosed {
+				log.Fatal(err)
+			}
+		}(listener)
+	}
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	stopping := make(chan interface{})
+	go sdWatchdog(service, stopping)
+	sdNotify("READY=1")
+
 	select {
 	case <-ctx.Done():
 	case <-quit:
 	}
 
 	log.Info("Shutting down daemon")
+	close(stopping)
+	sdNotify("STOPPING=1")
 	if err := s.Shutdown(ctx); err != nil && err != context.Canceled {
 		log.Errorf("Failed shutting down http server gracefully: %s", err)
 	}
 }
 
+// tcpListenerPort returns the port of the first TCP listener, or 0 if there is
+// none
+func tcpListenerPort(listeners []net.Listener) int {
+	for _, listener := range listeners {
+		if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+			return addr.Port
+		}
+	}
+	return 0
+}
+
 // @Summary Shutdown
 // @Description shutdown server
 // @ID shutdown
@@ -0,0 +1,21 @@
+package models
+
+type FileInfo struct {
+	Id     int    `json:"id"`
+	Length int64  `json:"length"`
+	Path   string `json:"path"`
+	Name   string `json:"name"`
+}
+
+type FileStatus struct {
+	Total             int64    `json:"total"`
+	TotalDone         int64    `json:"total_done"`
+	Progress          float64  `json:"progress"`
+	Priority          uint     `json:"priority"`
+	BufferingTotal    int64    `json:"buffering_total"`
+	BufferingProgress float64  `json:"buffering_progress"`
+	BufferingEta      int64    `json:"buffering_eta"`
+	Bitrate           int64    `json:"bitrate"`
+	SafeToStart       bool     `json:"safe_to_start"`
+	State             LTStatus `json:"state"`
+}
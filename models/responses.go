@@ -0,0 +1,29 @@
+// Package models holds the types exchanged through the REST API, so clients
+// can use them without depending on the service implementation
+package models
+
+type ErrorResponse struct {
+	Error string `json:"error" example:"Houston, we have a problem!"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message" example:"done"`
+}
+
+type NewTorrentResponse struct {
+	InfoHash string `json:"info_hash" example:"000102030405060708090a0b0c0d0e0f10111213"`
+}
+
+type TorrentInfoResponse struct {
+	*TorrentInfo
+	Status *TorrentStatus `json:"status,omitempty"`
+}
+
+type FileInfoResponse struct {
+	*FileInfo
+	Status *FileStatus `json:"status,omitempty"`
+}
+
+type MagnetResponse struct {
+	Magnet string `json:"magnet" example:"magnet:?xt=urn:btih:000102030405060708090a0b0c0d0e0f10111213"`
+}
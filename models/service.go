@@ -0,0 +1,12 @@
+package models
+
+type ServiceStatus struct {
+	Progress     float64 `json:"progress"`
+	DownloadRate int64   `json:"download_rate"`
+	UploadRate   int64   `json:"upload_rate"`
+	Downloaded   int64   `json:"downloaded"`
+	Uploaded     int64   `json:"uploaded"`
+	NumTorrents  int     `json:"num_torrents"`
+	IsPaused     bool    `json:"is_paused"`
+	BlockedPeers int64   `json:"blocked_peers"`
+}
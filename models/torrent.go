@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+)
+
+var InvalidStatusError = errors.New("invalid status")
+
+type LTStatus int
+
+//noinspection GoUnusedConst
+const (
+	QueuedStatus             LTStatus = iota // libtorrent.TorrentStatusUnusedEnumForBackwardsCompatibility
+	CheckingStatus                           // libtorrent.TorrentStatusCheckingFiles
+	FindingStatus                            // libtorrent.TorrentStatusDownloadingMetadata
+	DownloadingStatus                        // libtorrent.TorrentStatusDownloading
+	FinishedStatus                           // libtorrent.TorrentStatusFinished
+	SeedingStatus                            // libtorrent.TorrentStatusSeeding
+	AllocatingStatus                         // libtorrent.TorrentStatusAllocating
+	CheckingResumeDataStatus                 // libtorrent.TorrentStatusCheckingResumeData
+	// Custom status
+	PausedStatus
+	BufferingStatus
+)
+
+var statusNames = []string{"queued", "checking", "finding", "downloading", "finished",
+	"seeding", "allocating", "checking_resume_data", "paused", "buffering"}
+
+func (s LTStatus) String() string {
+	if s >= 0 && int(s) < len(statusNames) {
+		return statusNames[s]
+	}
+	return "unknown"
+}
+
+// ParseLTStatus parses a status either from its name or its numeric value
+func ParseLTStatus(value string) (LTStatus, error) {
+	for i, name := range statusNames {
+		if value == name || value == strconv.Itoa(i) {
+			return LTStatus(i), nil
+		}
+	}
+	return 0, InvalidStatusError
+}
+
+type TorrentInfo struct {
+	InfoHash     string `json:"info_hash"`
+	Name         string `json:"name"`
+	Size         int64  `json:"size"`
+	SelectedFile int    `json:"selected_file"`
+}
+
+type TorrentStatus struct {
+	Total           int64    `json:"total"`
+	TotalDone       int64    `json:"total_done"`
+	TotalWanted     int64    `json:"total_wanted"`
+	TotalWantedDone int64    `json:"total_wanted_done"`
+	Progress        float64  `json:"progress"`
+	DownloadRate    int      `json:"download_rate"`
+	UploadRate      int      `json:"upload_rate"`
+	Paused          bool     `json:"paused"`
+	HasMetadata     bool     `json:"has_metadata"`
+	State           LTStatus `json:"state"`
+	Seeders         int      `json:"seeders"`
+	SeedersTotal    int      `json:"seeders_total"`
+	Peers           int      `json:"peers"`
+	PeersTotal      int      `json:"peers_total"`
+	SeedingTime     int64    `json:"seeding_time"`
+	FinishedTime    int64    `json:"finished_time"`
+	ActiveTime      int64    `json:"active_time"`
+	AllTimeDownload int64    `json:"all_time_download"`
+	AllTimeUpload   int64    `json:"all_time_upload"`
+}
+
+type TorrentMetadata struct {
+	InfoHash     string   `json:"info_hash" example:"000102030405060708090a0b0c0d0e0f10111213"`
+	Name         string   `json:"name" example:"Big Buck Bunny"`
+	Size         int64    `json:"size" example:"276134947"`
+	HasMetadata  bool     `json:"has_metadata" example:"true"`
+	Comment      string   `json:"comment" example:"Big Buck Bunny, Blender Foundation"`
+	Creator      string   `json:"creator" example:"mktorrent 1.1"`
+	CreationDate int64    `json:"creation_date" example:"1589289328"`
+	PieceLength  int      `json:"piece_length" example:"262144"`
+	NumPieces    int      `json:"num_pieces" example:"1054"`
+	Private      bool     `json:"private" example:"false"`
+	NumFiles     int      `json:"num_files" example:"3"`
+	Trackers     []string `json:"trackers" example:"udp://tracker.opentrackr.org:1337/announce"`
+	WebSeeds     []string `json:"web_seeds" example:"https://webtorrent.io/torrents/"`
+}
@@ -60,20 +60,42 @@ type ProxySettings struct {
 	Password string    `json:"password"`
 }
 
+// PeerFingerprint defines the client id and version used for generating the
+// peer id, e.g. -LT1200- for libtorrent 1.2.0.0. Versions are encoded as a
+// single character, so each of them must be in the [0, 61] range.
+type PeerFingerprint struct {
+	ClientId string `json:"client_id" validate:"len=2,alphanum" example:"LT"`
+	Major    int    `json:"major" validate:"gte=0,lte=61" example:"1"`
+	Minor    int    `json:"minor" validate:"gte=0,lte=61" example:"2"`
+	Revision int    `json:"revision" validate:"gte=0,lte=61" example:"0"`
+	Tag      int    `json:"tag" validate:"gte=0,lte=61" example:"0"`
+}
+
+// Overrides maps libtorrent settings names to their values, which must be
+// strings, integers or booleans, according to the setting type
+type Overrides map[string]interface{}
+
 // Settings define the server settings
 type Settings struct {
-	settingsPath string `json:"-"`
+	settingsPath string            `json:"-"`
+	sources      map[string]Source `json:"-"`
+	// Settings from the file and the API, without the environment overrides,
+	// which are the ones saved
+	persisted *Settings `json:"-"`
 
 	ListenPort           uint             `json:"listen_port" validate:"gte=0,lte=65535" example:"6889"`
 	ListenInterfaces     string           `json:"listen_interfaces" example:""`
 	OutgoingInterfaces   string           `json:"outgoing_interfaces" example:""`
 	DisableDHT           bool             `json:"disable_dht" example:"false"`
+	DhtBootstrapNodes    []string         `json:"dht_bootstrap_nodes" example:"router.bittorrent.com:6881"`
 	DisableUPNP          bool             `json:"disable_upnp" example:"false"`
 	DisableNatPMP        bool             `json:"disable_natpmp" example:"false"`
 	DisableLSD           bool             `json:"disable_lsd" example:"false"`
 	DownloadPath         string           `json:"download_path" validate:"required" example:"downloads"`
 	TorrentsPath         string           `json:"torrents_path" validate:"required" example:"downloads/torrents"`
-	UserAgent            UserAgentType    `json:"user_agent" validate:"gte=0,lte=6" example:"0"`
+	UserAgent            UserAgentType    `json:"user_agent" validate:"gte=0,lte=11" example:"0"`
+	CustomUserAgent      string           `json:"custom_user_agent" example:""`
+	PeerFingerprint      *PeerFingerprint `json:"peer_fingerprint"`
 	SessionSave          time.Duration    `json:"session_save" validate:"gt=0" example:"30" swaggertype:"integer"`
 	TunedStorage         bool             `json:"tuned_storage" example:"false"`
 	CheckAvailableSpace  bool             `json:"check_available_space" example:"true"`
@@ -93,11 +115,14 @@ type Settings struct {
 	ActiveLimit          int              `json:"active_limit" example:"500"`
 	EncryptionPolicy     EncryptionPolicy `json:"encryption_policy" validate:"gte=0,lte=2" example:"0"`
 	Proxy                *ProxySettings   `json:"proxy"`
+	BlocklistSources     []string         `json:"blocklist_sources" example:"https://example.com/blocklist.p2p.gz"`
+	BlocklistRefresh     time.Duration    `json:"blocklist_refresh" validate:"gte=0" example:"86400" swaggertype:"integer"`
 	BufferSize           int64            `json:"buffer_size" example:"20971520"`
 	PieceWaitTimeout     time.Duration    `json:"piece_wait_timeout" validate:"gte=0" example:"60" swaggertype:"integer"`
 	ServiceLogLevel      logging.Level    `json:"service_log_level" validate:"gte=0,lte=5" example:"4" swaggertype:"integer"`
 	AlertsLogLevel       logging.Level    `json:"alerts_log_level" validate:"gte=0,lte=5" example:"0" swaggertype:"integer"`
 	ApiLogLevel          logging.Level    `json:"api_log_level" validate:"gte=0,lte=5" example:"1" swaggertype:"integer"`
+	LibtorrentOverrides  Overrides        `json:"libtorrent_overrides" swaggertype:"object"`
 }
 
 func DefaultSettings() *Settings {
@@ -107,12 +132,15 @@ func DefaultSettings() *Settings {
 		ListenInterfaces:     "",
 		OutgoingInterfaces:   "",
 		DisableDHT:           false,
+		DhtBootstrapNodes:    nil,
 		DisableUPNP:          false,
 		DisableNatPMP:        false,
 		DisableLSD:           false,
 		DownloadPath:         "downloads",
 		TorrentsPath:         filepath.Join("downloads", "torrents"),
 		UserAgent:            DefaultUA,
+		CustomUserAgent:      "",
+		PeerFingerprint:      nil,
 		SessionSave:          30,
 		TunedStorage:         false,
 		CheckAvailableSpace:  true,
@@ -132,21 +160,28 @@ func DefaultSettings() *Settings {
 		ActiveLimit:          500,
 		EncryptionPolicy:     EncryptionEnabledPolicy,
 		Proxy:                nil,
+		BlocklistSources:     nil,
+		BlocklistRefresh:     24 * 60 * 60,
 		BufferSize:           20 * 1024 * 1024,
 		PieceWaitTimeout:     60,
 		ServiceLogLevel:      logging.INFO,
 		AlertsLogLevel:       logging.CRITICAL,
 		ApiLogLevel:          logging.ERROR,
+		LibtorrentOverrides:  nil,
 	}
 }
 
-// Load loads settings from path
+// Load loads settings from path, which can be a JSON, YAML or TOML file,
+// according to its extension. Settings set on TORREST_* environment variables
+// take precedence over the ones on the file.
 func Load(path string) (s *Settings, err error) {
 	s = DefaultSettings()
 	s.SetSettingsPath(path)
 
-	if data, e := ioutil.ReadFile(path); e == nil {
-		err = s.Update(data)
+	if err = s.loadFile(path); err == nil {
+		if s.persisted, err = s.copy(); err == nil {
+			err = s.loadEnv()
+		}
 	}
 
 	return s, err
@@ -157,10 +192,27 @@ func (s *Settings) SetSettingsPath(path string) {
 	s.settingsPath = path
 }
 
-// Update updates the settings with the json object provided
-func (s *Settings) Update(data []byte) (err error) {
+// Update updates the settings with the json object provided. Libtorrent
+// overrides are merged with the existing ones, and removed if set to null.
+func (s *Settings) Update(data []byte) error {
+	if s.persisted != nil {
+		if err := s.persisted.update(data, ApiSource); err != nil {
+			return err
+		}
+	}
+	return s.update(data, ApiSource)
+}
+
+func (s *Settings) update(data []byte, source Source) (err error) {
 	if err = json.Unmarshal(data, s); err == nil {
-		err = validate.Struct(s)
+		for name, value := range s.LibtorrentOverrides {
+			if value == nil {
+				delete(s.LibtorrentOverrides, name)
+			}
+		}
+		if err = validate.Struct(s); err == nil {
+			s.setSources(source, jsonPaths(data)...)
+		}
 	}
 	return
 }
@@ -171,18 +223,51 @@ func (s *Settings) Clone() *Settings {
 	if err := n.UpdateFrom(s); err != nil {
 		panic("Failed cloning settings: " + err.Error())
 	}
+	// Make sure updating the clone does not change the original overrides
+	if s.LibtorrentOverrides != nil {
+		n.LibtorrentOverrides = make(Overrides, len(s.LibtorrentOverrides))
+		for name, value := range s.LibtorrentOverrides {
+			n.LibtorrentOverrides[name] = value
+		}
+	}
 	return n
 }
 
 // UpdateFrom updates the settings with the settings object provided
-func (s *Settings) UpdateFrom(settings *Settings) error {
+func (s *Settings) UpdateFrom(settings *Settings) (err error) {
+	s.sources = make(map[string]Source, len(settings.sources))
+	for path, source := range settings.sources {
+		s.sources[path] = source
+	}
+	s.persisted = nil
+	if settings.persisted != nil {
+		if s.persisted, err = settings.persisted.copy(); err != nil {
+			return
+		}
+	}
 	return copier.Copy(s, settings)
 }
 
-// Save saves the current settings to path
+// copy returns a deep copy of the settings values
+func (s *Settings) copy() (*Settings, error) {
+	n := &Settings{}
+	data, err := json.Marshal(s)
+	if err == nil {
+		err = json.Unmarshal(data, n)
+	}
+	return n, err
+}
+
+// Save saves the settings to path, in the format of the path extension.
+// Values set on environment variables are not saved, unless later changed
+// through Update.
 func (s *Settings) Save() (err error) {
+	saved := s
+	if s.persisted != nil {
+		saved = s.persisted
+	}
 	var data []byte
-	if data, err = json.MarshalIndent(s, "", "   "); err == nil {
+	if data, err = encodeFile(s.settingsPath, saved); err == nil {
 		err = ioutil.WriteFile(s.settingsPath, data, 0644)
 	}
 	return
@@ -0,0 +1,175 @@
+package settings
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func setEnv(t *testing.T, env map[string]string) {
+	for name, value := range env {
+		if err := os.Setenv(name, value); err != nil {
+			t.Fatal(err)
+		}
+		name := name
+		t.Cleanup(func() { _ = os.Unsetenv(name) })
+	}
+}
+
+func writeSettingsFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if content != "" {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		env      map[string]string
+		check    func(s *Settings) interface{}
+		expected interface{}
+		sources  map[string]Source
+		err      bool
+	}{
+		{
+			name:     "missing file",
+			file:     "settings.json",
+			check:    func(s *Settings) interface{} { return s.ListenPort },
+			expected: uint(6889),
+			sources:  map[string]Source{"listen_port": DefaultSource, "proxy.hostname": DefaultSource},
+		},
+		{
+			name:     "json",
+			file:     "settings.json",
+			content:  `{"listen_port": 7000, "proxy": {"hostname": "proxy.local", "port": 1080}}`,
+			check:    func(s *Settings) interface{} { return []interface{}{s.ListenPort, s.Proxy.Hostname, s.Proxy.Port} },
+			expected: []interface{}{uint(7000), "proxy.local", 1080},
+			sources:  map[string]Source{"listen_port": FileSource, "proxy.hostname": FileSource, "proxy.type": DefaultSource, "buffer_size": DefaultSource},
+		},
+		{
+			name:    "yaml",
+			file:    "settings.yml",
+			content: "listen_port: 7000\nproxy:\n  hostname: proxy.local\n  port: 1080\nlibtorrent_overrides:\n  enable_dht: false\n",
+			check: func(s *Settings) interface{} {
+				return []interface{}{s.ListenPort, s.Proxy.Hostname, s.LibtorrentOverrides["enable_dht"]}
+			},
+			expected: []interface{}{uint(7000), "proxy.local", false},
+			sources:  map[string]Source{"listen_port": FileSource, "proxy.port": FileSource, "libtorrent_overrides": FileSource},
+		},
+		{
+			name:     "empty yaml",
+			file:     "settings.yaml",
+			content:  "\n",
+			check:    func(s *Settings) interface{} { return s.ListenPort },
+			expected: uint(6889),
+			sources:  map[string]Source{"listen_port": DefaultSource},
+		},
+		{
+			name:     "toml",
+			file:     "settings.toml",
+			content:  "listen_port = 7000\ndht_bootstrap_nodes = [\"a:1\", \"b:2\"]\n\n[proxy]\nhostname = \"proxy.local\"\nport = 1080\n",
+			check:    func(s *Settings) interface{} { return []interface{}{s.ListenPort, s.DhtBootstrapNodes, s.Proxy.Port} },
+			expected: []interface{}{uint(7000), []string{"a:1", "b:2"}, 1080},
+			sources:  map[string]Source{"listen_port": FileSource, "dht_bootstrap_nodes": FileSource, "proxy.hostname": FileSource},
+		},
+		{
+			name:    "env overrides file",
+			file:    "settings.json",
+			content: `{"listen_port": 7000, "max_download_rate": 10}`,
+			env: map[string]string{
+				"TORREST_LISTEN_PORT":         "7001",
+				"TORREST_DHT_BOOTSTRAP_NODES": "a:1, b:2",
+				"TORREST_PROXY_HOSTNAME":      "proxy.local",
+				"TORREST_DISABLE_DHT":         "true",
+			},
+			check: func(s *Settings) interface{} {
+				return []interface{}{s.ListenPort, s.MaxDownloadRate, s.DhtBootstrapNodes, s.Proxy.Hostname, s.DisableDHT}
+			},
+			expected: []interface{}{uint(7001), 10, []string{"a:1", "b:2"}, "proxy.local", true},
+			sources: map[string]Source{
+				"listen_port":         EnvSource,
+				"max_download_rate":   FileSource,
+				"dht_bootstrap_nodes": EnvSource,
+				"proxy.hostname":      EnvSource,
+				"proxy.port":          DefaultSource,
+				"disable_dht":         EnvSource,
+			},
+		},
+		{
+			name:     "env json list",
+			file:     "settings.json",
+			env:      map[string]string{"TORREST_BLOCKLIST_SOURCES": `["https://a", "https://b"]`},
+			check:    func(s *Settings) interface{} { return s.BlocklistSources },
+			expected: []string{"https://a", "https://b"},
+			sources:  map[string]Source{"blocklist_sources": EnvSource},
+		},
+		{name: "invalid env value", file: "settings.json", env: map[string]string{"TORREST_LISTEN_PORT": "abc"}, err: true},
+		{name: "invalid file value", file: "settings.json", content: `{"listen_port": 70000}`, err: true},
+		{name: "invalid yaml", file: "settings.yaml", content: "listen_port: [", err: true},
+		{name: "invalid toml", file: "settings.toml", content: "listen_port = ", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setEnv(t, test.env)
+			s, err := Load(writeSettingsFile(t, test.file, test.content))
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %t, got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if value := test.check(s); !reflect.DeepEqual(value, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, value)
+			}
+			sources := s.Sources()
+			for path, source := range test.sources {
+				if sources[path] != source {
+					t.Errorf("expected %s source %s, got %s", path, source, sources[path])
+				}
+			}
+		})
+	}
+}
+
+func TestSaveWithoutEnv(t *testing.T) {
+	for _, name := range []string{"settings.json", "settings.yaml", "settings.toml"} {
+		t.Run(name, func(t *testing.T) {
+			path := writeSettingsFile(t, name, "")
+			setEnv(t, map[string]string{"TORREST_LISTEN_PORT": "7001"})
+			s, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Update([]byte(`{"max_upload_rate": 20}`)); err != nil {
+				t.Fatal(err)
+			}
+			if sources := s.Sources(); sources["max_upload_rate"] != ApiSource {
+				t.Errorf("expected max_upload_rate source %s, got %s", ApiSource, sources["max_upload_rate"])
+			}
+			if err := s.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			_ = os.Unsetenv("TORREST_LISTEN_PORT")
+			saved, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.ListenPort != 6889 {
+				t.Errorf("expected saved listen port 6889, got %d", saved.ListenPort)
+			}
+			if saved.MaxUploadRate != 20 {
+				t.Errorf("expected saved max upload rate 20, got %d", saved.MaxUploadRate)
+			}
+		})
+	}
+}
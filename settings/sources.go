@@ -0,0 +1,316 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding settings,
+// e.g. TORREST_LISTEN_PORT or TORREST_PROXY_HOSTNAME
+const EnvPrefix = "TORREST_"
+
+// Source identifies where a setting value came from
+type Source string
+
+const (
+	DefaultSource Source = "default"
+	FileSource    Source = "file"
+	EnvSource     Source = "env"
+	ApiSource     Source = "api"
+)
+
+type fileFormat int
+
+const (
+	jsonFormat fileFormat = iota
+	yamlFormat
+	tomlFormat
+)
+
+func formatFromPath(path string) fileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yamlFormat
+	case ".toml":
+		return tomlFormat
+	default:
+		return jsonFormat
+	}
+}
+
+// decodeFile decodes a settings file, converting it to JSON
+func decodeFile(path string, data []byte) ([]byte, error) {
+	switch formatFromPath(path) {
+	case yamlFormat:
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return []byte("{}"), nil
+		}
+		return json.Marshal(normalizeYaml(v))
+	case tomlFormat:
+		v := make(map[string]interface{})
+		if err := toml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	default:
+		return data, nil
+	}
+}
+
+// encodeFile encodes the settings in the format of the settings file
+func encodeFile(path string, s *Settings) ([]byte, error) {
+	format := formatFromPath(path)
+	if format == jsonFormat {
+		return json.MarshalIndent(s, "", "   ")
+	}
+
+	var v map[string]interface{}
+	if data, err := json.Marshal(s); err != nil {
+		return nil, err
+	} else if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	// Neither format has a null value
+	normalizeJson(v)
+
+	if format == yamlFormat {
+		return yaml.Marshal(v)
+	}
+	buf := &strings.Builder{}
+	err := toml.NewEncoder(buf).Encode(v)
+	return []byte(buf.String()), err
+}
+
+// normalizeYaml converts the maps decoded by yaml, which may have non string
+// keys, so they can be encoded as JSON
+func normalizeYaml(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, e := range value {
+			m[fmt.Sprint(k)] = normalizeYaml(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range value {
+			value[i] = normalizeYaml(e)
+		}
+	}
+	return v
+}
+
+// normalizeJson removes null values and converts integral numbers, which
+// are decoded as floats, back to integers
+func normalizeJson(m map[string]interface{}) {
+	for k, v := range m {
+		switch value := v.(type) {
+		case nil:
+			delete(m, k)
+		case float64:
+			if value == math.Trunc(value) {
+				m[k] = int64(value)
+			}
+		case map[string]interface{}:
+			normalizeJson(value)
+		}
+	}
+}
+
+// settingField is a leaf settings field, identified by the path of json
+// names, e.g. proxy.hostname
+type settingField struct {
+	path string
+	kind reflect.Kind
+}
+
+// settingsFields lists all the leaf fields of the provided type, recursing
+// into nested structs
+func settingsFields(t reflect.Type, prefix string) []settingField {
+	var fields []settingField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "" || name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			fields = append(fields, settingsFields(fieldType, prefix+name+".")...)
+		} else {
+			fields = append(fields, settingField{path: prefix + name, kind: fieldType.Kind()})
+		}
+	}
+	return fields
+}
+
+// envName returns the environment variable name of a setting path
+func envName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(path, ".", "_", -1))
+}
+
+// envValue converts the environment variable value to JSON, according to the
+// field kind. Lists may be provided either as JSON arrays or comma separated.
+func envValue(field settingField, value string) (json.RawMessage, error) {
+	var raw []byte
+	switch field.kind {
+	case reflect.String:
+		raw, _ = json.Marshal(value)
+	case reflect.Slice:
+		if strings.HasPrefix(strings.TrimSpace(value), "[") {
+			raw = []byte(value)
+		} else {
+			items := []string{}
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			raw, _ = json.Marshal(items)
+		}
+	default:
+		raw = []byte(value)
+	}
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("invalid value for %s: %q", envName(field.path), value)
+	}
+	return raw, nil
+}
+
+// envOverrides builds a JSON object with the settings set on environment
+// variables, returning also the overridden paths
+func envOverrides() ([]byte, []string, error) {
+	values := make(map[string]interface{})
+	var paths []string
+	for _, field := range settingsFields(reflect.TypeOf(Settings{}), "") {
+		value, ok := os.LookupEnv(envName(field.path))
+		if !ok {
+			continue
+		}
+		raw, err := envValue(field, value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		names := strings.Split(field.path, ".")
+		m := values
+		for _, name := range names[:len(names)-1] {
+			nested, ok := m[name].(map[string]interface{})
+			if !ok {
+				nested = make(map[string]interface{})
+				m[name] = nested
+			}
+			m = nested
+		}
+		m[names[len(names)-1]] = raw
+		paths = append(paths, field.path)
+	}
+	data, err := json.Marshal(values)
+	return data, paths, err
+}
+
+// jsonPaths returns the paths of the values set on the JSON object. Nested
+// objects are only traversed if they correspond to nested settings.
+func jsonPaths(data []byte) []string {
+	var v map[string]interface{}
+	if json.Unmarshal(data, &v) != nil {
+		return nil
+	}
+	nested := make(map[string]bool)
+	for _, field := range settingsFields(reflect.TypeOf(Settings{}), "") {
+		if i := strings.LastIndex(field.path, "."); i >= 0 {
+			nested[field.path[:i]] = true
+		}
+	}
+
+	var paths []string
+	var walk func(m map[string]interface{}, prefix string)
+	walk = func(m map[string]interface{}, prefix string) {
+		for k, e := range m {
+			path := prefix + k
+			if child, ok := e.(map[string]interface{}); ok && nested[path] {
+				walk(child, path+".")
+			} else {
+				paths = append(paths, path)
+			}
+		}
+	}
+	walk(v, "")
+	sort.Strings(paths)
+	return paths
+}
+
+func (s *Settings) setSources(source Source, paths ...string) {
+	if s.sources == nil {
+		s.sources = make(map[string]Source)
+	}
+	for _, path := range paths {
+		// A whole nested object set overrides the source of its fields
+		for p := range s.sources {
+			if strings.HasPrefix(p, path+".") {
+				delete(s.sources, p)
+			}
+		}
+		s.sources[path] = source
+	}
+}
+
+// Sources reports where each effective setting value came from, using the
+// JSON path of the setting, e.g. proxy.hostname, as key
+func (s *Settings) Sources() map[string]Source {
+	sources := make(map[string]Source)
+	for _, field := range settingsFields(reflect.TypeOf(Settings{}), "") {
+		sources[field.path] = DefaultSource
+		for path := field.path; ; path = path[:strings.LastIndex(path, ".")] {
+			if source, ok := s.sources[path]; ok {
+				sources[field.path] = source
+				break
+			}
+			if !strings.Contains(path, ".") {
+				break
+			}
+		}
+	}
+	return sources
+}
+
+// loadFile reads the settings file, if it exists
+func (s *Settings) loadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if data, err = decodeFile(path, data); err != nil {
+		return fmt.Errorf("failed decoding %s: %s", path, err)
+	}
+	return s.update(data, FileSource)
+}
+
+// loadEnv applies the settings set on environment variables
+func (s *Settings) loadEnv() error {
+	data, paths, err := envOverrides()
+	if err == nil && len(paths) > 0 {
+		err = s.update(data, EnvSource)
+	}
+	return err
+}
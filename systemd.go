@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/i96751414/torrest/bittorrent"
+)
+
+// First file descriptor passed by systemd socket activation
+const listenFdsStart = 3
+
+// systemdListeners returns the sockets passed by systemd socket activation
+// (LISTEN_FDS), if any. The environment variables are unset, so they are not
+// inherited by child processes.
+func systemdListeners() ([]net.Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	nFds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nFds <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	listeners := make([]net.Listener, 0, nFds)
+	for i := 0; i < nFds; i++ {
+		name := "LISTEN_FD_" + strconv.Itoa(listenFdsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(listenFdsStart+i), name)
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// unixListener listens on a Unix domain socket, removing any stale socket
+// left on the path, and sets the socket file permissions
+func unixListener(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// sdNotify sends a notification message to systemd, if NOTIFY_SOCKET is set
+func sdNotify(state string) {
+	// Abstract namespace sockets, prefixed with @, are handled by the net package
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		log.Errorf("Failed connecting to systemd notify socket: %s", err)
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		log.Errorf("Failed notifying systemd: %s", err)
+	}
+}
+
+// sdWatchdogInterval returns the interval at which the watchdog must be
+// notified, which is half of the configured timeout, or zero if the watchdog
+// is not enabled for this process
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// sdWatchdog periodically notifies the systemd watchdog while the service is
+// alive. Once stopping is closed, the service no longer runs and the stop
+// timeout is extended instead, so the daemon is not killed while saving the
+// torrents resume data.
+func sdWatchdog(service *bittorrent.Service, stopping <-chan interface{}) {
+	interval := sdWatchdogInterval()
+	if interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopping:
+			extendTimeout := "EXTEND_TIMEOUT_USEC=" + strconv.FormatInt(int64(2*interval/time.Microsecond), 10)
+			for range ticker.C {
+				sdNotify(extendTimeout)
+			}
+		case <-ticker.C:
+			if service.IsAlive(interval) {
+				sdNotify("WATCHDOG=1")
+			} else {
+				log.Warning("Service is not responding, skipping watchdog notification")
+			}
+		}
+	}
+}
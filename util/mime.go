@@ -0,0 +1,80 @@
+package util
+
+import (
+	"mime"
+	"path"
+	"strings"
+)
+
+// Most of these are not known by the mime package, or depend on the system
+// mime tables
+var mediaTypes = map[string]string{
+	".3gp":  "video/3gpp",
+	".avi":  "video/x-msvideo",
+	".divx": "video/x-msvideo",
+	".flv":  "video/x-flv",
+	".m2ts": "video/mp2t",
+	".m4v":  "video/x-m4v",
+	".mkv":  "video/x-matroska",
+	".mov":  "video/quicktime",
+	".mp4":  "video/mp4",
+	".mpeg": "video/mpeg",
+	".mpg":  "video/mpeg",
+	".mts":  "video/mp2t",
+	".ogv":  "video/ogg",
+	".ts":   "video/mp2t",
+	".vob":  "video/mpeg",
+	".webm": "video/webm",
+	".wmv":  "video/x-ms-wmv",
+	".aac":  "audio/aac",
+	".ac3":  "audio/ac3",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".mka":  "audio/x-matroska",
+	".mp3":  "audio/mpeg",
+	".oga":  "audio/ogg",
+	".ogg":  "audio/ogg",
+	".opus": "audio/opus",
+	".wav":  "audio/wav",
+	".wma":  "audio/x-ms-wma",
+	".bmp":  "image/bmp",
+	".gif":  "image/gif",
+	".jpeg": "image/jpeg",
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+	".srt":  "application/x-subrip",
+	".ssa":  "text/x-ssa",
+	".ass":  "text/x-ssa",
+	".vtt":  "text/vtt",
+	".sub":  "text/plain",
+	".nfo":  "text/plain",
+}
+
+// MimeTypeByName returns the mime type of a file given its name, or an empty
+// string if the extension is unknown
+func MimeTypeByName(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if mimeType, ok := mediaTypes[ext]; ok {
+		return mimeType
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// MediaMimeTypes returns the known mime types of media files
+func MediaMimeTypes() []string {
+	var mimeTypes []string
+	seen := make(map[string]bool)
+	for _, mimeType := range mediaTypes {
+		if !seen[mimeType] && isMedia(mimeType) {
+			seen[mimeType] = true
+			mimeTypes = append(mimeTypes, mimeType)
+		}
+	}
+	return mimeTypes
+}
+
+func isMedia(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "video/") || strings.HasPrefix(mimeType, "audio/") ||
+		strings.HasPrefix(mimeType, "image/")
+}